@@ -0,0 +1,60 @@
+package main
+
+import (
+	"cmp"
+	"math"
+	"slices"
+)
+
+const (
+	defaultAlternatives = 3
+	maxAlternatives     = 20
+)
+
+// Weights of the similarity components, they add up to 1. Movers without services
+// on either side are compared by rating alone
+const (
+	ratingSimilarityWeight  = 0.6
+	serviceSimilarityWeight = 0.4
+)
+
+// similarity scores how close another mover is to the base one, from 0 to 1. Rating closeness
+// counts the most, overlapping services (Jaccard index) refine it
+func similarity(base, other mover) float64 {
+	ratingSimilarity := 1 - math.Abs(base.Rating-other.Rating)/5.0
+	if len(base.Services) == 0 || len(other.Services) == 0 {
+		return ratingSimilarity
+	}
+
+	shared := 0
+	for _, service := range other.Services {
+		if slices.Contains(base.Services, service) {
+			shared++
+		}
+	}
+	union := len(base.Services) + len(other.Services) - shared
+	serviceSimilarity := float64(shared) / float64(union)
+	return ratingSimilarityWeight*ratingSimilarity + serviceSimilarityWeight*serviceSimilarity
+}
+
+// alternativesFor returns the candidates most similar to the base mover, without the base mover itself.
+// Equally similar movers are ordered by rank, then by ID
+func alternativesFor(base mover, candidates []mover, r ranker, n int) []mover {
+	alternatives := []mover{}
+	for _, candidate := range candidates {
+		if candidate.ID != base.ID {
+			alternatives = append(alternatives, candidate)
+		}
+	}
+
+	slices.SortStableFunc(alternatives, func(a, b mover) int {
+		if result := cmp.Compare(similarity(base, b), similarity(base, a)); result != 0 {
+			return result
+		}
+		if result := cmp.Compare(r.score(b), r.score(a)); result != 0 {
+			return result
+		}
+		return compareIdentity(a, b)
+	})
+	return alternatives[:min(n, len(alternatives))]
+}
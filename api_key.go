@@ -0,0 +1,29 @@
+package main
+
+import (
+	"crypto/subtle"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// adminOnly lets a handler run only for requests whose X-API-Key header matches ADMIN_API_KEY.
+// Without a configured key the endpoint is disabled, so it is never open by accident
+func (s *server) adminOnly(handler gin.HandlerFunc) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		if s.config.AdminAPIKey == "" {
+			context.JSON(http.StatusForbidden, gin.H{"error": "Admin endpoints are disabled, ADMIN_API_KEY is not set"})
+			return
+		}
+		if !s.validAPIKey(context.GetHeader("X-API-Key")) {
+			context.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing API key"})
+			return
+		}
+		handler(context)
+	}
+}
+
+// validAPIKey reports whether the key is the configured ADMIN_API_KEY. Always false without one.
+// Constant-time comparison, so response times don't leak how much of a guessed key is right
+func (s *server) validAPIKey(key string) bool {
+	return s.config.AdminAPIKey != "" && subtle.ConstantTimeCompare([]byte(key), []byte(s.config.AdminAPIKey)) == 1
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const availabilityTimeLayout = "15:04"
+
+var workweek = []string{"monday", "tuesday", "wednesday", "thursday", "friday"}
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// availabilityWindow is a weekly time range in local wall-clock time, e.g. monday 08:00-18:00.
+// End is exclusive
+type availabilityWindow struct {
+	Weekday string `json:"weekday"`
+	Start   string `json:"start"`
+	End     string `json:"end"`
+}
+
+// availabilityOn builds the same start-end window for every given weekday
+func availabilityOn(start, end string, days ...string) []availabilityWindow {
+	windows := make([]availabilityWindow, 0, len(days))
+	for _, day := range days {
+		windows = append(windows, availabilityWindow{Weekday: day, Start: start, End: end})
+	}
+	return windows
+}
+
+// normalizeAvailability lowercases weekdays and rejects unknown days, malformed times and start not before end
+func normalizeAvailability(windows []availabilityWindow) ([]availabilityWindow, error) {
+	normalized := make([]availabilityWindow, 0, len(windows))
+	for _, window := range windows {
+		window.Weekday = strings.ToLower(strings.TrimSpace(window.Weekday))
+		if _, ok := weekdays[window.Weekday]; !ok {
+			return nil, fmt.Errorf("availability weekday %q is not valid", window.Weekday)
+		}
+
+		start, startErr := time.Parse(availabilityTimeLayout, window.Start)
+		end, endErr := time.Parse(availabilityTimeLayout, window.End)
+		if startErr != nil || endErr != nil {
+			return nil, errors.New("availability times should use the HH:MM format")
+		}
+		if !start.Before(end) {
+			return nil, fmt.Errorf("availability on %s should start before it ends", window.Weekday)
+		}
+		// Zero-padded times compare correctly as strings in isAvailableAt
+		window.Start = start.Format(availabilityTimeLayout)
+		window.End = end.Format(availabilityTimeLayout)
+		normalized = append(normalized, window)
+	}
+	return normalized, nil
+}
+
+// isAvailableAt reports whether any window covers the wall-clock time of at, in at's own time zone
+func isAvailableAt(windows []availabilityWindow, at time.Time) bool {
+	clock := at.Format(availabilityTimeLayout)
+	for _, window := range windows {
+		if weekdays[window.Weekday] == at.Weekday() && window.Start <= clock && clock < window.End {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+)
+
+const defaultMaxBodyBytes = 1 << 20 // 1 MB
+
+// bodyLimitMiddleware caps request bodies at limit bytes. Bodies that announce a larger
+// Content-Length are rejected right away, others fail while being read
+func bodyLimitMiddleware(limit int64) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		if context.Request.ContentLength > limit {
+			context.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": bodyTooLargeMessage(limit)})
+			return
+		}
+		context.Request.Body = http.MaxBytesReader(context.Writer, context.Request.Body, limit)
+		context.Next()
+	}
+}
+
+func bodyTooLargeMessage(limit int64) string {
+	return fmt.Sprintf("Request body should not be larger than %d bytes", limit)
+}
+
+// respondBindError answers 413 when binding failed because the body went over the size limit,
+// otherwise 400 with the given message
+func respondBindError(context *gin.Context, err error, message string) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		context.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": bodyTooLargeMessage(maxBytesErr.Limit)})
+		return
+	}
+	context.JSON(http.StatusBadRequest, gin.H{"error": message})
+}
+
+// bufferBody reads the size-capped request body into memory and puts it back for binding.
+// The lock wrappers call it before locking the store, so a client trickling its body in
+// never holds up the other requests. Returns the body so a retried handler can read it again.
+// Answers the error itself and reports false on failure
+func bufferBody(context *gin.Context) ([]byte, bool) {
+	if context.Request.Body == nil || context.Request.Body == http.NoBody {
+		return nil, true
+	}
+	body, err := io.ReadAll(context.Request.Body)
+	if err != nil {
+		respondBindError(context, err, "Could not read the request body")
+		return nil, false
+	}
+	context.Request.Body = io.NopCloser(bytes.NewReader(body))
+	return body, true
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOversizedBodyIsRejected(t *testing.T) {
+	config := testConfig()
+	config.MaxBodyBytes = 1024
+	router := initializeRouter(config, newTestStore(t))
+
+	// A bulk import of one valid mover padded past the limit with whitespace
+	oversized := `[{"name": "Huge Movers", "telephone_number": "+15551000001"}` + strings.Repeat(" ", 1024) + "]"
+	for _, path := range []string{"/v1/movers", "/v1/movers/bulk", "/v1/movers/1/review"} {
+		for _, announced := range []bool{true, false} {
+			t.Run(fmt.Sprintf("%s announced=%t", path, announced), func(t *testing.T) {
+				request := httptest.NewRequest(http.MethodPost, path, strings.NewReader(oversized))
+				request.Header.Set("Content-Type", "application/json")
+				if !announced {
+					// Chunked upload, the limit is only hit while reading
+					request.ContentLength = -1
+				}
+				recorder := httptest.NewRecorder()
+				router.ServeHTTP(recorder, request)
+				expectStatus(t, recorder, http.StatusRequestEntityTooLarge)
+				if message := decode[map[string]string](t, recorder)["error"]; message != bodyTooLargeMessage(1024) {
+					t.Errorf("error %q, want %q", message, bodyTooLargeMessage(1024))
+				}
+			})
+		}
+	}
+
+	// The same import without the padding fits
+	expectStatus(t, doRequest(router, http.MethodPost, "/v1/movers/bulk", `[{"name": "Huge Movers", "telephone_number": "+15551000001"}]`), http.StatusCreated)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// A comparison of more movers than this doesn't fit side by side anyway
+const maxCompareIds = 5
+
+// compareSummary holds the ID of the best mover per category, null when no mover qualifies
+type compareSummary struct {
+	HighestRating *int `json:"highest_rating"`
+	MostJobs      *int `json:"most_jobs"`
+	Cheapest      *int `json:"cheapest"` // Movers without an hourly rate don't compete
+}
+
+// parseCompareIds splits the comma-separated ids param, keeping the given order
+func parseCompareIds(param string) ([]int, error) {
+	if strings.TrimSpace(param) == "" {
+		return nil, fmt.Errorf("ids should list the mover IDs to compare, e.g. ids=3,5,10")
+	}
+
+	parts := strings.Split(param, ",")
+	if len(parts) > maxCompareIds {
+		return nil, fmt.Errorf("ids should list at most %d movers", maxCompareIds)
+	}
+
+	ids := make([]int, 0, len(parts))
+	seen := map[int]bool{}
+	for _, part := range parts {
+		id, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("Mover ID %q should be a number", strings.TrimSpace(part))
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("Mover ID %d is listed twice", id)
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// summarizeComparison picks the best mover per category. If values are equal, the lower ID wins
+func summarizeComparison(movers []mover) compareSummary {
+	var summary compareSummary
+	var highestRated, mostJobs, cheapest *mover
+	for i := range movers {
+		m := &movers[i]
+		if highestRated == nil || m.Rating > highestRated.Rating || (m.Rating == highestRated.Rating && m.ID < highestRated.ID) {
+			highestRated = m
+		}
+		if mostJobs == nil || m.JobsAmount > mostJobs.JobsAmount || (m.JobsAmount == mostJobs.JobsAmount && m.ID < mostJobs.ID) {
+			mostJobs = m
+		}
+		if m.HourlyRate > 0 && (cheapest == nil || m.HourlyRate < cheapest.HourlyRate || (m.HourlyRate == cheapest.HourlyRate && m.ID < cheapest.ID)) {
+			cheapest = m
+		}
+	}
+	if highestRated != nil {
+		summary.HighestRating = &highestRated.ID
+	}
+	if mostJobs != nil {
+		summary.MostJobs = &mostJobs.ID
+	}
+	if cheapest != nil {
+		summary.Cheapest = &cheapest.ID
+	}
+	return summary
+}
+
+// GET request. Side-by-side comparison of a few movers, in the order of the ids param
+func (s *server) compareMovers(context *gin.Context) {
+	ids, err := parseCompareIds(context.Query("ids"))
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	compared, notFound := []mover{}, []int{}
+	for _, id := range ids {
+		m, getErr := s.store.Get(id)
+		if getErr != nil {
+			notFound = append(notFound, id)
+			continue
+		}
+		compared = append(compared, m)
+	}
+	if len(notFound) > 0 {
+		context.JSON(http.StatusNotFound, gin.H{"message": "Mover not found", "not_found": notFound})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"movers": outputPrecision(context).movers(compared),
+		"best":   summarizeComparison(compared),
+	})
+}
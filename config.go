@@ -0,0 +1,219 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Config holds every setting of the service. It is loaded once at startup and passed
+// explicitly to whatever needs it
+type Config struct {
+	Host                string  // HOST, defaults to localhost
+	Port                string  // PORT, defaults to 8080
+	BayesianPriorWeight float64 // BAYESIAN_PRIOR_WEIGHT, prior weight of the ranking
+	NormalizeOnLoad     bool    // NORMALIZE_ON_LOAD, normalize loaded movers at startup
+	// RATING_PRECISION, decimals of ratings in responses, or full. Requests can override it with ?precision=
+	RatingPrecision ratingPrecision
+	// REJECT_DUPLICATE_REVIEWERS, reject a second review of a mover from the same reviewer
+	RejectDuplicateReviewers bool
+
+	// Timeouts take Go durations such as 5s or 1m, 0 disables them
+	ReadTimeout    time.Duration // READ_TIMEOUT, time to read a whole request
+	WriteTimeout   time.Duration // WRITE_TIMEOUT, time to write a response
+	IdleTimeout    time.Duration // IDLE_TIMEOUT, how long keep-alive connections stay open
+	RequestTimeout time.Duration // REQUEST_TIMEOUT, handlers running longer get a 503
+
+	WebhookURL string // WEBHOOK_URL, notified about every new review. Empty disables it
+
+	MaxBodyBytes int64 // MAX_BODY_BYTES, larger request bodies get a 413
+
+	IdempotencyTTL time.Duration // IDEMPOTENCY_TTL, how long an Idempotency-Key replays its response
+	// REVIEW_DEDUP_WINDOW, an identical review from the same submitter within it isn't recorded again
+	ReviewDedupWindow time.Duration
+
+	MaxEventSubscribers int // MAX_EVENT_SUBSCRIBERS, concurrent GET /movers/events streams, more get a 503
+
+	ReadOnly bool // READ_ONLY, start in read-only mode, writes get a 503 until an admin turns it off
+
+	AdminAPIKey string // ADMIN_API_KEY, X-API-Key of the admin endpoints. Empty disables them
+
+	// Requests per minute, 0 disables the limit
+	RateLimit      int // RATE_LIMIT, per client IP for requests without the admin key
+	AdminRateLimit int // ADMIN_RATE_LIMIT, for requests with the admin key, 0 (the default) exempts them
+
+	Store      string // STORE, memory (the default) or sqlite
+	SQLitePath string // SQLITE_PATH, database file of the sqlite store, defaults to movers.db
+	// STORE_WRITE_RETRIES, how often a SQLite write failing because the database is busy is retried
+	StoreWriteRetries int
+
+	LogLevel  slog.Level // LOG_LEVEL, debug, info (the default), warn or error
+	LogFormat string     // LOG_FORMAT, text (the default) or json
+}
+
+// Storage backends selectable with STORE
+const (
+	memoryBackend = "memory"
+	sqliteBackend = "sqlite"
+)
+
+// Address returns the host:port the server listens on
+func (config Config) Address() string {
+	return fmt.Sprintf("%s:%s", config.Host, config.Port)
+}
+
+// LoadConfig reads the configuration from environment variables, applies defaults and validates it
+func LoadConfig() (Config, error) {
+	config := Config{
+		Host:                     envOrDefault("HOST", "localhost"),
+		Port:                     envOrDefault("PORT", "8080"),
+		BayesianPriorWeight:      defaultBayesianPriorWeight,
+		RatingPrecision:          defaultRatingPrecision,
+		RejectDuplicateReviewers: true,
+		ReadTimeout:              10 * time.Second,
+		WriteTimeout:             15 * time.Second,
+		IdleTimeout:              60 * time.Second,
+		RequestTimeout:           5 * time.Second,
+		WebhookURL:               os.Getenv("WEBHOOK_URL"),
+		MaxBodyBytes:             defaultMaxBodyBytes,
+		IdempotencyTTL:           defaultIdempotencyTTL,
+		ReviewDedupWindow:        defaultReviewDedupWindow,
+		MaxEventSubscribers:      defaultMaxEventSubscribers,
+		AdminAPIKey:              os.Getenv("ADMIN_API_KEY"),
+		Store:                    envOrDefault("STORE", memoryBackend),
+		SQLitePath:               envOrDefault("SQLITE_PATH", "movers.db"),
+		StoreWriteRetries:        defaultStoreWriteRetries,
+		LogLevel:                 slog.LevelInfo,
+		LogFormat:                envOrDefault("LOG_FORMAT", textLogFormat),
+	}
+
+	if config.Store != memoryBackend && config.Store != sqliteBackend {
+		return Config{}, fmt.Errorf("STORE should be %s or %s, got %q", memoryBackend, sqliteBackend, config.Store)
+	}
+
+	if logLevel := os.Getenv("LOG_LEVEL"); logLevel != "" {
+		level, err := parseLogLevel(logLevel)
+		if err != nil {
+			return Config{}, fmt.Errorf("LOG_LEVEL %v", err)
+		}
+		config.LogLevel = level
+	}
+	if config.LogFormat != textLogFormat && config.LogFormat != jsonLogFormat {
+		return Config{}, fmt.Errorf("LOG_FORMAT should be %s or %s, got %q", textLogFormat, jsonLogFormat, config.LogFormat)
+	}
+
+	if port, err := strconv.Atoi(config.Port); err != nil || port < 1 || port > 65535 {
+		return Config{}, fmt.Errorf("PORT should be a number between 1 and 65535, got %q", config.Port)
+	}
+
+	if priorWeight := os.Getenv("BAYESIAN_PRIOR_WEIGHT"); priorWeight != "" {
+		weight, err := strconv.ParseFloat(priorWeight, 64)
+		if err != nil || weight < 0 {
+			return Config{}, fmt.Errorf("BAYESIAN_PRIOR_WEIGHT should be a non-negative number, got %q", priorWeight)
+		}
+		config.BayesianPriorWeight = weight
+	}
+
+	if normalizeOnLoad := os.Getenv("NORMALIZE_ON_LOAD"); normalizeOnLoad != "" {
+		enabled, err := strconv.ParseBool(normalizeOnLoad)
+		if err != nil {
+			return Config{}, fmt.Errorf("NORMALIZE_ON_LOAD should be true or false, got %q", normalizeOnLoad)
+		}
+		config.NormalizeOnLoad = enabled
+	}
+
+	if precision := os.Getenv("RATING_PRECISION"); precision != "" {
+		parsed, err := parseRatingPrecision(precision)
+		if err != nil {
+			return Config{}, fmt.Errorf("RATING_PRECISION: %v", err)
+		}
+		config.RatingPrecision = parsed
+	}
+
+	if readOnly := os.Getenv("READ_ONLY"); readOnly != "" {
+		enabled, err := strconv.ParseBool(readOnly)
+		if err != nil {
+			return Config{}, fmt.Errorf("READ_ONLY should be true or false, got %q", readOnly)
+		}
+		config.ReadOnly = enabled
+	}
+
+	if rejectDuplicates := os.Getenv("REJECT_DUPLICATE_REVIEWERS"); rejectDuplicates != "" {
+		enabled, err := strconv.ParseBool(rejectDuplicates)
+		if err != nil {
+			return Config{}, fmt.Errorf("REJECT_DUPLICATE_REVIEWERS should be true or false, got %q", rejectDuplicates)
+		}
+		config.RejectDuplicateReviewers = enabled
+	}
+
+	if config.WebhookURL != "" {
+		webhookURL, err := url.Parse(config.WebhookURL)
+		if err != nil || (webhookURL.Scheme != "http" && webhookURL.Scheme != "https") || webhookURL.Host == "" {
+			return Config{}, fmt.Errorf("WEBHOOK_URL should be an http or https URL, got %q", config.WebhookURL)
+		}
+	}
+
+	if maxBodyBytes := os.Getenv("MAX_BODY_BYTES"); maxBodyBytes != "" {
+		limit, err := strconv.ParseInt(maxBodyBytes, 10, 64)
+		if err != nil || limit < 1 {
+			return Config{}, fmt.Errorf("MAX_BODY_BYTES should be a positive number of bytes, got %q", maxBodyBytes)
+		}
+		config.MaxBodyBytes = limit
+	}
+
+	if maxSubscribers := os.Getenv("MAX_EVENT_SUBSCRIBERS"); maxSubscribers != "" {
+		limit, err := strconv.Atoi(maxSubscribers)
+		if err != nil || limit < 1 {
+			return Config{}, fmt.Errorf("MAX_EVENT_SUBSCRIBERS should be a positive number, got %q", maxSubscribers)
+		}
+		config.MaxEventSubscribers = limit
+	}
+
+	if writeRetries := os.Getenv("STORE_WRITE_RETRIES"); writeRetries != "" {
+		retries, err := strconv.Atoi(writeRetries)
+		if err != nil || retries < 0 {
+			return Config{}, fmt.Errorf("STORE_WRITE_RETRIES should be a non-negative number, got %q", writeRetries)
+		}
+		config.StoreWriteRetries = retries
+	}
+
+	for key, limit := range map[string]*int{"RATE_LIMIT": &config.RateLimit, "ADMIN_RATE_LIMIT": &config.AdminRateLimit} {
+		if value := os.Getenv(key); value != "" {
+			perMinute, err := strconv.Atoi(value)
+			if err != nil || perMinute < 0 {
+				return Config{}, fmt.Errorf("%s should be a non-negative number of requests per minute, got %q", key, value)
+			}
+			*limit = perMinute
+		}
+	}
+
+	timeouts := map[string]*time.Duration{
+		"READ_TIMEOUT":        &config.ReadTimeout,
+		"WRITE_TIMEOUT":       &config.WriteTimeout,
+		"IDLE_TIMEOUT":        &config.IdleTimeout,
+		"REQUEST_TIMEOUT":     &config.RequestTimeout,
+		"IDEMPOTENCY_TTL":     &config.IdempotencyTTL,
+		"REVIEW_DEDUP_WINDOW": &config.ReviewDedupWindow,
+	}
+	for key, timeout := range timeouts {
+		if value := os.Getenv(key); value != "" {
+			duration, err := time.ParseDuration(value)
+			if err != nil || duration < 0 {
+				return Config{}, fmt.Errorf("%s should be a non-negative duration such as 5s, got %q", key, value)
+			}
+			*timeout = duration
+		}
+	}
+
+	return config, nil
+}
+
+func envOrDefault(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
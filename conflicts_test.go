@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+// Names that differ from a seed mover's only in casing or surrounding whitespace
+var nameVariants = []string{"Rapid Movers", "rapid movers", "RAPID MOVERS", "Rapid Movers ", "  rApId mOvErS  "}
+
+func TestNameVariantsConflict(t *testing.T) {
+	router := newTestRouter(t)
+	for i, name := range nameVariants {
+		t.Run(fmt.Sprintf("%q", name), func(t *testing.T) {
+			body := fmt.Sprintf(`{"name": %q, "telephone_number": "+1555070%04d"}`, name, i)
+			expectStatus(t, doRequest(router, http.MethodPost, "/v1/movers", body), http.StatusConflict)
+			expectStatus(t, doRequest(router, http.MethodPost, "/v1/movers/bulk", "["+body+"]"), http.StatusBadRequest)
+			expectStatus(t, doRequest(router, http.MethodPatch, "/v1/movers/1", fmt.Sprintf(`{"name": %q}`, name)), http.StatusConflict)
+
+			// Renaming mover 2 to a variant of its own name isn't a conflict
+			recorder := doRequest(router, http.MethodPatch, "/v1/movers/2", fmt.Sprintf(`{"name": %q}`, name))
+			expectStatus(t, recorder, http.StatusOK)
+		})
+	}
+
+	// Matching ignores casing, the display name keeps it as entered
+	recorder := doRequest(router, http.MethodPatch, "/v1/movers/2", `{"name": "rapid MOVERS"}`)
+	expectStatus(t, recorder, http.StatusOK)
+	if name := decode[mover](t, recorder).Name; name != "rapid MOVERS" {
+		t.Errorf("display name %q, want %q", name, "rapid MOVERS")
+	}
+	body := `{"name": "San Francisco Mov ", "telephone_number": "+15550709999"}`
+	expectStatus(t, doRequest(router, http.MethodPost, "/v1/movers", body), http.StatusConflict)
+}
+
+func TestTelNumberFormatsCollide(t *testing.T) {
+	// Mover 1 has +15615557689
+	formats := []string{"+1 561-555-7689", "+1 (561) 555-7689", "+1.561.555.7689", "+1 561 555 7689", "+1-561-5557689"}
+	router := newTestRouter(t)
+	for i, format := range formats {
+		t.Run(format, func(t *testing.T) {
+			body := fmt.Sprintf(`{"name": "Format Movers %d", "telephone_number": %q}`, i, format)
+			expectStatus(t, doRequest(router, http.MethodPost, "/v1/movers", body), http.StatusConflict)
+			expectStatus(t, doRequest(router, http.MethodPost, "/v1/movers/bulk", "["+body+"]"), http.StatusBadRequest)
+			expectStatus(t, doRequest(router, http.MethodPatch, "/v1/movers/2", fmt.Sprintf(`{"telephone_number": %q}`, format)), http.StatusConflict)
+
+			recorder := doRequest(router, http.MethodGet, "/v1/movers/by-phone/"+url.PathEscape(format), "")
+			expectStatus(t, recorder, http.StatusOK)
+			if id := decode[mover](t, recorder).ID; id != 1 {
+				t.Errorf("by-phone found mover %d, want 1", id)
+			}
+		})
+	}
+
+	// Two new movers sending the same number in different formats, it's stored canonical
+	recorder := doRequest(router, http.MethodPost, "/v1/movers", `{"name": "Canonical Movers", "telephone_number": "+1 (555) 080-0001"}`)
+	expectStatus(t, recorder, http.StatusCreated)
+	if stored := decode[mover](t, recorder).TelephoneNumber; stored != "+15550800001" {
+		t.Errorf("stored %q, want +15550800001", stored)
+	}
+	expectStatus(t, doRequest(router, http.MethodPost, "/v1/movers", `{"name": "Copycat Movers", "telephone_number": "+1-555-080-0001"}`), http.StatusConflict)
+}
+
+func TestUpdateTelNumberUniqueness(t *testing.T) {
+	router := newTestRouter(t)
+	ownNumber := defaultMovers()[0].TelephoneNumber
+	otherNumber := defaultMovers()[1].TelephoneNumber
+	replacement := func(telNumber string) string {
+		return fmt.Sprintf(`{"name": "San Francisco MOV", "telephone_number": %q, "rating": 4.6, "jobs_done": 3780, "review_count": 912}`, telNumber)
+	}
+
+	// Keeping the same number, also written differently, isn't a conflict with itself
+	expectStatus(t, doRequest(router, http.MethodPut, "/v1/movers/1", replacement(ownNumber)), http.StatusOK)
+	expectStatus(t, doRequest(router, http.MethodPut, "/v1/movers/1", replacement("+1 (561) 555-7689")), http.StatusOK)
+	expectStatus(t, doRequest(router, http.MethodPatch, "/v1/movers/1", fmt.Sprintf(`{"telephone_number": %q}`, ownNumber)), http.StatusOK)
+	expectStatus(t, doRequest(router, http.MethodPatch, "/v1/movers/1", `{"hourly_rate": 140}`), http.StatusOK)
+
+	// Taking mover 2's number is
+	for _, request := range []struct{ method, body string }{
+		{http.MethodPut, replacement(otherNumber)},
+		{http.MethodPatch, fmt.Sprintf(`{"telephone_number": %q}`, otherNumber)},
+	} {
+		recorder := doRequest(router, request.method, "/v1/movers/1", request.body)
+		expectStatus(t, recorder, http.StatusConflict)
+		if message := decode[map[string]string](t, recorder)["error"]; message != "Tel. number is occupied" {
+			t.Errorf("%s: error %q", request.method, message)
+		}
+	}
+	if m := decode[mover](t, doRequest(router, http.MethodGet, "/v1/movers/1", "")); m.TelephoneNumber != ownNumber {
+		t.Errorf("mover 1 has %s after the rejected updates, want %s", m.TelephoneNumber, ownNumber)
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// rankCursor is the opaque pagination token. It holds the rank key of the last mover a client saw,
+// so the next page starts right after it even if movers were added or deleted in between
+type rankCursor struct {
+	Featured bool    `json:"f,omitempty"`
+	Score    float64 `json:"s"`
+	ID       int     `json:"i"`
+}
+
+func encodeCursor(m mover, r ranker) string {
+	data, _ := json.Marshal(rankCursor{Featured: m.Featured, Score: r.score(m), ID: m.ID})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(token string) (rankCursor, error) {
+	var cursor rankCursor
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || json.Unmarshal(data, &cursor) != nil {
+		return rankCursor{}, errors.New("cursor is not valid")
+	}
+	return cursor, nil
+}
+
+// rankedAfter reports whether the mover comes after the cursor in featured-first/score-desc/id-asc order
+func (cursor rankCursor) rankedAfter(m mover, r ranker) bool {
+	if result := rankOrder(cursor.Featured, cursor.Score, m.Featured, r.score(m)); result != 0 {
+		return result < 0
+	}
+	return m.ID > cursor.ID
+}
+
+// pageAfterCursor returns up to limit movers of the ranked list that come after the cursor
+// and the remaining movers after that page
+func pageAfterCursor(sortedMovers []mover, cursor *rankCursor, limit int, r ranker) (page []mover, rest []mover) {
+	start := 0
+	if cursor != nil {
+		start = len(sortedMovers)
+		for i, mover := range sortedMovers {
+			if cursor.rankedAfter(mover, r) {
+				start = i
+				break
+			}
+		}
+	}
+
+	end := min(start+limit, len(sortedMovers))
+	return sortedMovers[start:end], sortedMovers[end:]
+}
+
+// listCursor is the pagination token of GET /movers. It holds the sort and the sort key values of the
+// last mover a client saw, so the next page starts right after it whatever the sort is and ties on the
+// sort keys are still broken by ID
+type listCursor struct {
+	Sort      string    `json:"o"`
+	Featured  bool      `json:"f"`
+	Score     float64   `json:"s"`
+	ID        int       `json:"i"`
+	Name      string    `json:"n"`
+	Rating    float64   `json:"r"`
+	Jobs      int       `json:"j"`
+	Rate      float64   `json:"h"`
+	CreatedAt time.Time `json:"c"`
+	Response  float64   `json:"t"`
+	Samples   int       `json:"u"`
+}
+
+func encodeListCursor(m mover, sort string, r ranker) string {
+	data, _ := json.Marshal(listCursor{
+		Sort:      sort,
+		Featured:  m.Featured,
+		Score:     r.score(m),
+		ID:        m.ID,
+		Name:      m.Name,
+		Rating:    m.Rating,
+		Jobs:      m.JobsAmount,
+		Rate:      m.HourlyRate,
+		CreatedAt: m.CreatedAt,
+		Response:  m.AvgResponseMinutes,
+		Samples:   m.ResponseSamples,
+	})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeListCursor(token string) (listCursor, error) {
+	var cursor listCursor
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || json.Unmarshal(data, &cursor) != nil {
+		return listCursor{}, errors.New("cursor is not valid")
+	}
+	return cursor, nil
+}
+
+// mover rebuilds the sort key fields of the last seen mover, so it can be compared like any other
+func (cursor listCursor) mover() mover {
+	return mover{
+		ID:         cursor.ID,
+		Name:       cursor.Name,
+		Rating:     cursor.Rating,
+		JobsAmount: cursor.Jobs,
+		HourlyRate: cursor.Rate,
+		CreatedAt:  cursor.CreatedAt,
+		Featured:   cursor.Featured,
+
+		AvgResponseMinutes: cursor.Response,
+		ResponseSamples:    cursor.Samples,
+	}
+}
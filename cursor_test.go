@@ -0,0 +1,60 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"testing"
+)
+
+func TestRecommendationFeedWalk(t *testing.T) {
+	router := newTestRouter(t)
+	ranked := rankedIds(t, router, "/v1/movers")
+	highQuality := 0
+	for _, m := range defaultMovers() {
+		if m.Rating >= highQualityRatingMinimum {
+			highQuality++
+		}
+	}
+
+	walked := []int{}
+	remaining, after := highQuality, ""
+	for pages := 0; ; pages++ {
+		if pages > len(ranked) {
+			t.Fatalf("the feed didn't end after %d pages", pages)
+		}
+		recorder := doRequest(router, http.MethodGet, "/v1/movers/recommend/feed?limit=4&after="+url.QueryEscape(after), "")
+		expectStatus(t, recorder, http.StatusOK)
+		page := decode[struct {
+			Movers               []mover `json:"movers"`
+			NextCursor           string  `json:"next_cursor"`
+			RemainingHighQuality int     `json:"remaining_high_quality"`
+		}](t, recorder)
+
+		onPage := 0
+		for _, m := range page.Movers {
+			walked = append(walked, m.ID)
+			if m.Rating >= highQualityRatingMinimum {
+				onPage++
+			}
+		}
+		if page.RemainingHighQuality != remaining-onPage {
+			t.Errorf("page %d: remaining_high_quality %d, want %d - %d on this page", pages, page.RemainingHighQuality, remaining, onPage)
+		}
+		if onPage > 0 && page.RemainingHighQuality >= remaining {
+			t.Errorf("page %d: remaining_high_quality didn't decrease from %d", pages, remaining)
+		}
+		remaining = page.RemainingHighQuality
+		if page.NextCursor == "" {
+			break
+		}
+		after = page.NextCursor
+	}
+
+	if remaining != 0 {
+		t.Errorf("%d high-quality movers left at the end of the feed", remaining)
+	}
+	if !slices.Equal(walked, ranked) {
+		t.Errorf("the feed walked %v, the ranking is %v", walked, ranked)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Media type clients can put in Accept instead of ?envelope=true
+const envelopeMediaType = "application/vnd.movers.envelope+json"
+
+// wantsEnvelope reports whether the request asked for enveloped responses
+func wantsEnvelope(context *gin.Context) (bool, error) {
+	if envelope := context.Query("envelope"); envelope != "" {
+		return strconv.ParseBool(envelope)
+	}
+	return strings.Contains(context.GetHeader("Accept"), envelopeMediaType), nil
+}
+
+// envelopeMiddleware wraps JSON responses in {"data": ...}, and error responses in
+// {"errors": [...]}, for clients that ask for it. Everyone else keeps the bare shapes.
+// Other content, like CSV exports and metrics, is never wrapped
+func envelopeMiddleware() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		context.Writer.Header().Add("Vary", "Accept")
+		enveloped, err := wantsEnvelope(context)
+		if err != nil {
+			context.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "envelope should be true or false"})
+			return
+		}
+		if !enveloped || isEventStream(context.Request) {
+			context.Next()
+			return
+		}
+
+		original := context.Writer
+		buffered := &bufferedWriter{ResponseWriter: original}
+		context.Writer = buffered
+		context.Next()
+		context.Writer = original
+
+		body := buffered.body.Bytes()
+		isJSON := strings.HasPrefix(original.Header().Get("Content-Type"), "application/json")
+		if !isJSON || len(body) == 0 || original.Written() {
+			_, _ = original.Write(body)
+			return
+		}
+
+		var wrapped any = gin.H{"data": json.RawMessage(body)}
+		if buffered.Status() >= http.StatusBadRequest {
+			wrapped = gin.H{"errors": []json.RawMessage{body}}
+		}
+		wrappedBody, _ := json.Marshal(wrapped)
+		original.Header().Del("Content-Length")
+		_, _ = original.Write(wrappedBody)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"github.com/gin-gonic/gin"
+	"math"
+	"net/http"
+	"strconv"
+)
+
+// The estimate model: loading and unloading take a fixed number of hours, driving is billed at the
+// hourly rate too at an average speed that allows for roads being longer than the straight line
+const (
+	estimateHandlingHours   = 3.0
+	estimateDrivingSpeedKmh = 50.0
+)
+
+// moveEstimate is a rough price of a move, not a quote
+type moveEstimate struct {
+	MoverID        int     `json:"mover_id"`
+	DistanceKm     float64 `json:"distance_km"` // Straight-line distance between the two points
+	EstimatedHours float64 `json:"estimated_hours"`
+	HourlyRate     float64 `json:"hourly_rate"`
+	EstimatedCost  float64 `json:"estimated_cost"`
+}
+
+func estimateMove(m mover, distanceKm float64) moveEstimate {
+	hours := estimateHandlingHours + distanceKm/estimateDrivingSpeedKmh
+	return moveEstimate{
+		MoverID:        m.ID,
+		DistanceKm:     math.Round(distanceKm*100) / 100,
+		EstimatedHours: math.Round(hours*10) / 10,
+		HourlyRate:     math.Round(m.HourlyRate*100) / 100,
+		EstimatedCost:  math.Round(hours*m.HourlyRate*100) / 100,
+	}
+}
+
+// GET request. Rough price of a move between two points with the mover's hourly rate
+func (s *server) getMoveEstimate(context *gin.Context) {
+	MoverId, err := extractId(context)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Mover ID should be a number"})
+		return
+	}
+
+	coordinates := map[string]float64{}
+	for _, name := range []string{"from_lat", "from_lng", "to_lat", "to_lng"} {
+		value, parseErr := strconv.ParseFloat(context.Query(name), 64)
+		if parseErr != nil || !isFinite(value) {
+			context.JSON(http.StatusBadRequest, gin.H{"error": "from_lat, from_lng, to_lat and to_lng query params are required numbers"})
+			return
+		}
+		coordinates[name] = value
+	}
+	for _, point := range []string{"from", "to"} {
+		if err := validateCoordinates(coordinates[point+"_lat"], coordinates[point+"_lng"]); err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"error": point + " " + err.Error()})
+			return
+		}
+	}
+
+	existingMover, getErr := s.store.Get(MoverId)
+	if getErr != nil {
+		context.JSON(http.StatusNotFound, gin.H{"message": "Mover not found"})
+		return
+	}
+	if existingMover.HourlyRate == 0 {
+		context.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Mover has no hourly rate to estimate with"})
+		return
+	}
+
+	distanceKm := haversineKm(coordinates["from_lat"], coordinates["from_lng"], coordinates["to_lat"], coordinates["to_lng"])
+	context.JSON(http.StatusOK, estimateMove(existingMover, distanceKm))
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestEstimateRejectsNonFiniteCoordinates(t *testing.T) {
+	router := newTestRouter(t)
+	valid := "from_lat=37.77&from_lng=-122.42&to_lat=37.80&to_lng=-122.27"
+	expectStatus(t, doRequest(router, http.MethodGet, "/v1/movers/1/estimate?"+valid, ""), http.StatusOK)
+
+	for _, query := range []string{
+		"from_lat=NaN&from_lng=-122.42&to_lat=37.80&to_lng=-122.27",
+		"from_lat=37.77&from_lng=nan&to_lat=37.80&to_lng=-122.27",
+		"from_lat=37.77&from_lng=-122.42&to_lat=Inf&to_lng=-122.27",
+		"from_lat=37.77&from_lng=-122.42&to_lat=37.80&to_lng=-Infinity",
+	} {
+		recorder := doRequest(router, http.MethodGet, "/v1/movers/1/estimate?"+query, "")
+		expectStatus(t, recorder, http.StatusBadRequest)
+		if message := decode[map[string]string](t, recorder)["error"]; message == "" {
+			t.Errorf("%s: 400 without an error message", query)
+		}
+	}
+}
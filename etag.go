@@ -0,0 +1,35 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// etagFor is a strong ETag over the exact response body, so it changes whenever
+// a mover in the response is added, deleted or re-rated
+func etagFor(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// gzipETag is the ETag of the gzip-compressed body. A strong ETag promises identical bytes,
+// so the compressed and the plain response can't share one
+func gzipETag(etag string) string {
+	if !strings.HasPrefix(etag, `"`) || strings.HasSuffix(etag, `-gzip"`) {
+		return etag
+	}
+	return strings.TrimSuffix(etag, `"`) + `-gzip"`
+}
+
+// etagMatches reports whether an If-None-Match header lists the ETag, of the plain or the
+// gzip-compressed body. Weak validators match too, which is what If-None-Match asks for
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag || candidate == gzipETag(etag) {
+			return true
+		}
+	}
+	return false
+}
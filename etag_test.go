@@ -0,0 +1,44 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestETagDiffersPerEncoding(t *testing.T) {
+	router := newTestRouter(t)
+
+	plain := doRequest(router, http.MethodGet, "/v1/movers", "")
+	expectStatus(t, plain, http.StatusOK)
+	compressed := doRequest(router, http.MethodGet, "/v1/movers", "", "Accept-Encoding", "gzip")
+	expectStatus(t, compressed, http.StatusOK)
+	if compressed.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatal("the mover list wasn't compressed, the test needs a larger response")
+	}
+
+	plainETag, gzipTag := plain.Header().Get("ETag"), compressed.Header().Get("ETag")
+	if plainETag == "" || gzipTag != gzipETag(plainETag) || gzipTag == plainETag {
+		t.Fatalf("plain ETag %s, gzip ETag %s, want the gzip one to be the plain one with -gzip", plainETag, gzipTag)
+	}
+	for _, header := range []http.Header{plain.Header(), compressed.Header()} {
+		if vary := header.Get("Vary"); vary != "Accept-Encoding" {
+			t.Errorf("Vary %q, want Accept-Encoding", vary)
+		}
+	}
+
+	// Each client revalidates with the ETag it got and is told its copy is still current
+	revalidated := doRequest(router, http.MethodGet, "/v1/movers", "", "If-None-Match", plainETag)
+	expectStatus(t, revalidated, http.StatusNotModified)
+	if etag := revalidated.Header().Get("ETag"); etag != plainETag {
+		t.Errorf("304 for the plain client has ETag %s, want %s", etag, plainETag)
+	}
+	revalidated = doRequest(router, http.MethodGet, "/v1/movers", "", "Accept-Encoding", "gzip", "If-None-Match", gzipTag)
+	expectStatus(t, revalidated, http.StatusNotModified)
+	if etag := revalidated.Header().Get("ETag"); etag != gzipTag {
+		t.Errorf("304 for the gzip client has ETag %s, want %s", etag, gzipTag)
+	}
+
+	// A change to the list invalidates both
+	expectStatus(t, doRequest(router, http.MethodPost, "/v1/movers/1/review", `{"rating": 1}`), http.StatusOK)
+	expectStatus(t, doRequest(router, http.MethodGet, "/v1/movers", "", "Accept-Encoding", "gzip", "If-None-Match", gzipTag), http.StatusOK)
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event types of GET /movers/events
+const (
+	moverAddedEvent    = "mover_added"
+	moverUpdatedEvent  = "mover_updated"
+	moverDeletedEvent  = "mover_deleted"
+	moverRestoredEvent = "mover_restored"
+	moverRatedEvent    = "mover_rated"
+)
+
+const (
+	defaultMaxEventSubscribers = 100
+	// Proxies close connections idle for a minute or so, a comment every 15s keeps them open
+	eventHeartbeatInterval = 15 * time.Second
+	// Events a subscriber can fall behind by before it's dropped, see publish
+	eventBufferSize = 32
+	// Seconds a client turned away for too many subscribers is asked to wait
+	eventsRetryAfter = 5
+)
+
+var errTooManySubscribers = errors.New("too many event subscribers")
+
+// moverEvent is one change streamed to subscribers. Mover is the mover after the change
+type moverEvent struct {
+	Type  string
+	Mover mover
+}
+
+// eventHub fans mover events out to every subscriber. Handlers publish under the store's write lock,
+// so subscribers see the changes in the order they were made
+type eventHub struct {
+	lock           sync.Mutex
+	maxSubscribers int
+	subscribers    map[chan moverEvent]bool
+}
+
+func newEventHub(maxSubscribers int) *eventHub {
+	return &eventHub{maxSubscribers: maxSubscribers, subscribers: map[chan moverEvent]bool{}}
+}
+
+func (hub *eventHub) subscribe() (chan moverEvent, error) {
+	hub.lock.Lock()
+	defer hub.lock.Unlock()
+	if len(hub.subscribers) >= hub.maxSubscribers {
+		return nil, errTooManySubscribers
+	}
+	events := make(chan moverEvent, eventBufferSize)
+	hub.subscribers[events] = true
+	return events, nil
+}
+
+// unsubscribe removes the subscriber unless publish already dropped it
+func (hub *eventHub) unsubscribe(events chan moverEvent) {
+	hub.lock.Lock()
+	defer hub.lock.Unlock()
+	if hub.subscribers[events] {
+		delete(hub.subscribers, events)
+		close(events)
+	}
+}
+
+// publish never blocks the handler. A subscriber whose buffer is full is dropped and its channel
+// closed, so it reconnects instead of silently missing events
+func (hub *eventHub) publish(eventType string, movers ...mover) {
+	hub.lock.Lock()
+	defer hub.lock.Unlock()
+	for _, m := range movers {
+		for events := range hub.subscribers {
+			select {
+			case events <- moverEvent{Type: eventType, Mover: m}:
+			default:
+				delete(hub.subscribers, events)
+				close(events)
+			}
+		}
+	}
+}
+
+// isEventStream reports whether the request is for GET /movers/events, under any prefix.
+// The stream runs for as long as the client stays, so it has to skip the request timeout
+// and the middlewares that buffer whole responses
+func isEventStream(request *http.Request) bool {
+	return request.Method == http.MethodGet && strings.HasSuffix(request.URL.Path, "/movers/events")
+}
+
+// GET request. Server-Sent Events stream of mover changes. Deliberately not readLocked,
+// the stream would hold the read lock and block every write for as long as it runs
+func (s *server) streamMoverEvents(context *gin.Context) {
+	events, err := s.events.subscribe()
+	if err != nil {
+		context.Header("Retry-After", strconv.Itoa(eventsRetryAfter))
+		context.JSON(http.StatusServiceUnavailable, gin.H{"error": "Too many event subscribers, retry later"})
+		return
+	}
+	defer s.events.unsubscribe(events)
+
+	// WRITE_TIMEOUT is meant for regular responses, it would cut the stream off
+	_ = http.NewResponseController(context.Writer).SetWriteDeadline(time.Time{})
+
+	context.Header("Content-Type", "text/event-stream")
+	context.Header("Cache-Control", "no-cache")
+	context.Header("X-Accel-Buffering", "no")
+	context.Status(http.StatusOK)
+	_, _ = fmt.Fprint(context.Writer, ": connected\n\n")
+	context.Writer.Flush()
+
+	precision := outputPrecision(context)
+	heartbeat := time.NewTicker(eventHeartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-context.Request.Context().Done():
+			return
+		case <-heartbeat.C:
+			_, _ = fmt.Fprint(context.Writer, ": heartbeat\n\n")
+		case event, open := <-events:
+			if !open {
+				return
+			}
+			data, err := json.Marshal(precision.mover(event.Mover))
+			if err != nil {
+				continue
+			}
+			_, _ = fmt.Fprintf(context.Writer, "event: %s\ndata: %s\n\n", event.Type, data)
+		}
+		context.Writer.Flush()
+	}
+}
+
+// publishDeleted publishes the soft-deleted mover as it is stored
+func (s *server) publishDeleted(moverId int) {
+	if deleted, err := s.store.Find(moverId); err == nil {
+		s.events.publish(moverDeletedEvent, deleted)
+	}
+}
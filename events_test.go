@@ -0,0 +1,70 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+// Every request that changes a mover publishes exactly one event about it
+func TestEveryMoverChangePublishesAnEvent(t *testing.T) {
+	router, s := newRouter(testConfig())
+	s.serve(newTestStore(t))
+	events, err := s.events.subscribe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.events.unsubscribe(events)
+
+	changes := []struct {
+		method, path, body string
+		admin              bool
+		wantType           string
+		wantId             int
+	}{
+		{http.MethodPost, "/v1/movers", `{"name": "Evented Movers", "telephone_number": "+15550200001"}`, false, moverAddedEvent, 16},
+		{http.MethodPut, "/v1/movers/16", `{"name": "Evented Movers", "telephone_number": "+15550200001", "hourly_rate": 90}`, false, moverUpdatedEvent, 16},
+		{http.MethodPatch, "/v1/movers/16", `{"hourly_rate": 95}`, false, moverUpdatedEvent, 16},
+		{http.MethodPost, "/v1/movers/16/response-time", `{"minutes": 12}`, false, moverUpdatedEvent, 16},
+		{http.MethodPost, "/v1/movers/16/verify", "", true, moverUpdatedEvent, 16},
+		{http.MethodPost, "/v1/movers/16/unverify", "", true, moverUpdatedEvent, 16},
+		{http.MethodPost, "/v1/movers/16/feature", "", true, moverUpdatedEvent, 16},
+		{http.MethodPost, "/v1/movers/16/unfeature", "", true, moverUpdatedEvent, 16},
+		{http.MethodPost, "/v1/movers/16/review", `{"rating": 4}`, false, moverRatedEvent, 16},
+		{http.MethodDelete, "/v1/movers/16/reviews/1", "", true, moverRatedEvent, 16},
+		{http.MethodDelete, "/v1/movers/16", "", false, moverDeletedEvent, 16},
+		{http.MethodPost, "/v1/movers/16/restore", "", false, moverRestoredEvent, 16},
+		{http.MethodDelete, "/v1/movers", `{"ids": [16]}`, false, moverDeletedEvent, 16},
+	}
+	for _, change := range changes {
+		headers := []string{}
+		if change.admin {
+			headers = append(headers, "X-API-Key", testAPIKey)
+		}
+		recorder := doRequest(router, change.method, change.path, change.body, headers...)
+		if recorder.Code >= 300 {
+			t.Fatalf("%s %s: status %d, body %s", change.method, change.path, recorder.Code, recorder.Body.String())
+		}
+
+		select {
+		case event := <-events:
+			if event.Type != change.wantType || event.Mover.ID != change.wantId {
+				t.Errorf("%s %s: event %s for mover %d, want %s for %d", change.method, change.path, event.Type, event.Mover.ID, change.wantType, change.wantId)
+			}
+		default:
+			t.Errorf("%s %s published no event", change.method, change.path)
+		}
+		select {
+		case event := <-events:
+			t.Errorf("%s %s published a second event %s", change.method, change.path, event.Type)
+		default:
+		}
+	}
+
+	// Setting a flag the mover already has changes nothing and publishes nothing
+	expectStatus(t, adminRequest(router, http.MethodPost, "/v1/movers/1/unverify", ""), http.StatusOK)
+	select {
+	case event := <-events:
+		t.Errorf("unchanged flag published %s", event.Type)
+	default:
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// moverFields are the JSON field names of a mover, what ?fields= can select
+var moverFields = jsonFieldNames(reflect.TypeOf(mover{}))
+
+func jsonFieldNames(t reflect.Type) []string {
+	names := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// fieldMask is a sparse fieldset, the mover fields a response is limited to. nil keeps every field
+type fieldMask []string
+
+// parseFieldMask parses the comma-separated ?fields= param, e.g. fields=id,name,rating
+func parseFieldMask(param string, present bool) (fieldMask, error) {
+	if !present {
+		return nil, nil
+	}
+	mask := fieldMask{}
+	for _, field := range strings.Split(param, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if !slices.Contains(moverFields, field) {
+			return nil, fmt.Errorf("fields: unknown field %q, known fields are %s", field, strings.Join(moverFields, ", "))
+		}
+		if !slices.Contains(mask, field) {
+			mask = append(mask, field)
+		}
+	}
+	if len(mask) == 0 {
+		return nil, fmt.Errorf("fields should list at least one field, e.g. fields=id,name,rating")
+	}
+	return mask, nil
+}
+
+// mover returns the mover limited to the masked fields. The mover is encoded first,
+// so the masked fields are rounded the same way as in the full object
+func (mask fieldMask) mover(m mover) any {
+	if mask == nil {
+		return m
+	}
+	encoded, err := json.Marshal(m)
+	if err != nil {
+		panic(err)
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(encoded, &all); err != nil {
+		panic(err)
+	}
+	masked := make(map[string]json.RawMessage, len(mask))
+	for _, field := range mask {
+		masked[field] = all[field]
+	}
+	return masked
+}
+
+func (mask fieldMask) movers(movers []mover) any {
+	if mask == nil {
+		return movers
+	}
+	masked := make([]any, len(movers))
+	for i, m := range movers {
+		masked[i] = mask.mover(m)
+	}
+	return masked
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"strings"
+)
+
+// fuzzySortKeys order fuzzy name search results by default: closest match first, then by rating
+var fuzzySortKeys = []sortKey{{Field: "match"}, {Field: "rating", Desc: true}}
+
+// maxNameDistance caps the edit distance of a fuzzy match at a quarter of the query length,
+// so "releable" may be 2 edits off while queries of up to 3 letters have to match exactly
+func maxNameDistance(query string) int {
+	return len([]rune(query)) / 4
+}
+
+// nameDistance is the smallest Levenshtein distance between the query and any run of as many
+// consecutive words of the name, case-insensitive. Names containing the query are 0 away
+func nameDistance(name, query string) int {
+	name, query = strings.ToLower(name), strings.ToLower(query)
+	if strings.Contains(name, query) {
+		return 0
+	}
+
+	words, queryWords := strings.Fields(name), len(strings.Fields(query))
+	if len(words) <= queryWords {
+		return levenshtein([]rune(name), []rune(query))
+	}
+	best := -1
+	for start := 0; start+queryWords <= len(words); start++ {
+		distance := levenshtein([]rune(strings.Join(words[start:start+queryWords], " ")), []rune(query))
+		if best < 0 || distance < best {
+			best = distance
+		}
+	}
+	return best
+}
+
+// levenshtein counts the single-rune insertions, deletions and substitutions turning a into b
+func levenshtein(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			substitution := previous[j-1]
+			if a[i-1] != b[j-1] {
+				substitution++
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, substitution)
+		}
+		previous = current
+	}
+	return previous[len(b)]
+}
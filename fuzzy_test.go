@@ -0,0 +1,51 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"testing"
+)
+
+// moverNames returns the names of a mover list response in order
+func moverNames(t *testing.T, router http.Handler, path string) []string {
+	t.Helper()
+	recorder := doRequest(router, http.MethodGet, path, "")
+	expectStatus(t, recorder, http.StatusOK)
+	names := []string{}
+	for _, m := range decode[[]mover](t, recorder) {
+		names = append(names, m.Name)
+	}
+	return names
+}
+
+func TestFuzzyNameSearch(t *testing.T) {
+	router := newTestRouter(t)
+	cases := []struct {
+		query string
+		fuzzy []string
+		exact []string
+	}{
+		{"releable", []string{"Reliable Relocations"}, []string{}},
+		{"rapdi movers", []string{"Rapid Movers"}, []string{}},
+		{"spedy transprot", []string{"Speedy Transport"}, []string{}},
+		// Both are 2 edits away, the higher rated one comes first
+		{"relocatoin", []string{"Swift Relocation", "Ace Relocators"}, []string{}},
+		// 7 letters allow a single edit, a swapped pair is two
+		{"premeir", []string{}, []string{}},
+		{"xyzzy", []string{}, []string{}},
+		// Contained names match exactly either way
+		{"reli", []string{"Reliable Relocations"}, []string{"Reliable Relocations"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.query, func(t *testing.T) {
+			query := url.QueryEscape(tc.query)
+			if got := moverNames(t, router, "/v1/movers?fuzzy=true&name="+query); !slices.Equal(got, tc.fuzzy) {
+				t.Errorf("fuzzy search found %q, want %q", got, tc.fuzzy)
+			}
+			if got := moverNames(t, router, "/v1/movers?name="+query); !slices.Equal(got, tc.exact) {
+				t.Errorf("default search found %q, want %q", got, tc.exact)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Responses smaller than this are sent as is, gzip would barely save anything on them
+const gzipMinSize = 1024
+
+// bufferedWriter holds the response body back so gzipMiddleware can decide on compression
+// once the handler is done and the final size and headers are known
+type bufferedWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+// acceptsGzip reports whether the Accept-Encoding header allows gzip, honoring an explicit q=0
+func acceptsGzip(acceptEncoding string) bool {
+	for _, encoding := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(encoding, ";")
+		if name = strings.TrimSpace(name); name != "gzip" && name != "*" {
+			continue
+		}
+		quality := 1.0
+		if key, value, _ := strings.Cut(params, "="); strings.TrimSpace(key) == "q" {
+			quality, _ = strconv.ParseFloat(strings.TrimSpace(value), 64)
+		}
+		return quality > 0
+	}
+	return false
+}
+
+// gzipMiddleware compresses responses of at least gzipMinSize bytes for clients that accept gzip.
+// Responses that already have a Content-Encoding, like gzipped metrics, are never compressed twice.
+// Responses accepting byte ranges stay uncompressed, so Range offsets always mean the same bytes
+func gzipMiddleware() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		context.Header("Vary", "Accept-Encoding")
+		if !acceptsGzip(context.GetHeader("Accept-Encoding")) || isEventStream(context.Request) {
+			context.Next()
+			return
+		}
+
+		original := context.Writer
+		buffered := &bufferedWriter{ResponseWriter: original}
+		context.Writer = buffered
+		context.Next()
+		context.Writer = original
+
+		// A 304 confirms the representation the client has, which is the gzip one if it sent that ETag
+		if etag := original.Header().Get("ETag"); original.Status() == http.StatusNotModified &&
+			etag != gzipETag(etag) && strings.Contains(context.GetHeader("If-None-Match"), gzipETag(etag)) {
+			original.Header().Set("ETag", gzipETag(etag))
+		}
+
+		// Headers already sent by the handler, e.g. through AbortWithStatus, can't announce gzip anymore
+		body := buffered.body.Bytes()
+		if len(body) < gzipMinSize || original.Header().Get("Content-Encoding") != "" ||
+			original.Header().Get("Accept-Ranges") != "" || original.Written() {
+			_, _ = original.Write(body)
+			return
+		}
+
+		original.Header().Set("Content-Encoding", "gzip")
+		original.Header().Del("Content-Length")
+		if etag := original.Header().Get("ETag"); etag != "" {
+			original.Header().Set("ETag", gzipETag(etag))
+		}
+		compressor := gzip.NewWriter(original)
+		_, _ = compressor.Write(body)
+		_ = compressor.Close()
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const defaultIdempotencyTTL = 24 * time.Hour
+
+// idempotentResponse is a response kept for an Idempotency-Key so a retried request gets it back
+type idempotentResponse struct {
+	requestHash [sha256.Size]byte
+	status      int
+	header      http.Header
+	body        []byte
+	expiresAt   time.Time
+}
+
+// idempotencyCache maps Idempotency-Key values to the response of the request that first used them
+type idempotencyCache struct {
+	lock      sync.Mutex
+	ttl       time.Duration
+	responses map[string]idempotentResponse
+}
+
+func newIdempotencyCache(ttl time.Duration) *idempotencyCache {
+	return &idempotencyCache{ttl: ttl, responses: map[string]idempotentResponse{}}
+}
+
+func (cache *idempotencyCache) get(key string, at time.Time) (idempotentResponse, bool) {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	response, found := cache.responses[key]
+	if found && at.After(response.expiresAt) {
+		delete(cache.responses, key)
+		return idempotentResponse{}, false
+	}
+	return response, found
+}
+
+// put stores the response and drops expired ones, so the cache doesn't grow without bound
+func (cache *idempotencyCache) put(key string, response idempotentResponse, at time.Time) {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	for storedKey, stored := range cache.responses {
+		if at.After(stored.expiresAt) {
+			delete(cache.responses, storedKey)
+		}
+	}
+	response.expiresAt = at.Add(cache.ttl)
+	cache.responses[key] = response
+}
+
+// idempotent replays the original response when a request is retried with the same Idempotency-Key,
+// instead of running the handler again. Only successful responses are kept, so a failed request
+// can be retried with the same key. Reusing a key with a different body is a 422
+func (s *server) idempotent(handler gin.HandlerFunc) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		key := context.GetHeader("Idempotency-Key")
+		if key == "" {
+			handler(context)
+			return
+		}
+
+		body, err := io.ReadAll(context.Request.Body)
+		if err != nil {
+			respondBindError(context, err, "Could not read the request body")
+			return
+		}
+		context.Request.Body = io.NopCloser(bytes.NewReader(body))
+		requestHash := sha256.Sum256(body)
+
+		if response, found := s.idempotency.get(key, time.Now()); found {
+			if response.requestHash != requestHash {
+				context.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used with a different request body"})
+				return
+			}
+			for name, values := range response.header {
+				context.Writer.Header()[name] = values
+			}
+			context.Header("Idempotent-Replayed", "true")
+			context.Data(response.status, response.header.Get("Content-Type"), response.body)
+			return
+		}
+
+		original := context.Writer
+		buffered := &bufferedWriter{ResponseWriter: original}
+		context.Writer = buffered
+		handler(context)
+		context.Writer = original
+
+		if status := buffered.Status(); status >= 200 && status < 300 {
+			header := http.Header{}
+			for _, name := range []string{"Content-Type", "Location"} {
+				if value := original.Header().Get(name); value != "" {
+					header.Set(name, value)
+				}
+			}
+			s.idempotency.put(key, idempotentResponse{requestHash: requestHash, status: status, header: header, body: buffered.body.Bytes()}, time.Now())
+		}
+		_, _ = original.Write(buffered.body.Bytes())
+	}
+}
@@ -0,0 +1,384 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultSortKey    = "rank"
+	maxListLimit      = 100
+	defaultCursorSize = 20
+)
+
+// Sortable fields of GET /movers, each comparator orders two movers ascending.
+// rank is handled separately because it needs the ranker
+var moverSortFields = map[string]func(a, b mover) int{
+	"id":       func(a, b mover) int { return cmp.Compare(a.ID, b.ID) },
+	"name":     func(a, b mover) int { return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name)) },
+	"rating":   func(a, b mover) int { return cmp.Compare(a.Rating, b.Rating) },
+	"jobs":     func(a, b mover) int { return cmp.Compare(a.JobsAmount, b.JobsAmount) },
+	"rate":     func(a, b mover) int { return cmp.Compare(a.HourlyRate, b.HourlyRate) },
+	"created":  func(a, b mover) int { return a.CreatedAt.Compare(b.CreatedAt) },
+	"response": func(a, b mover) int { return cmp.Compare(responseSortKey(a), responseSortKey(b)) },
+}
+
+// listOptions holds every GET /movers query option:
+//
+//	name        case-insensitive substring of the mover name
+//	fuzzy       true matches name with typos too, see nameDistance. Sorts by match, then -rating
+//	min_rating  minimum rating
+//	min_rate    minimum hourly rate
+//	max_rate    maximum hourly rate
+//	min_jobs    minimum jobs done
+//	max_jobs    maximum jobs done
+//	featured    true for featured movers only, false for the others
+//	verified    true for verified movers only, false for the others. Independent of featured
+//	service     service the mover offers, case-insensitive. Repeat it to require all of them
+//	sort        comma-separated keys out of rank, id, name, rating, jobs, rate, created, response,
+//	            and match with fuzzy, applied in order. A leading minus sorts that key descending, e.g. -rating,-jobs,name.
+//	            Defaults to rank, the Bayesian ranking with the best mover first
+//	limit       page size, 1 to 100. Omitted means the whole list, or 20 movers with a cursor
+//	offset      number of movers to skip, defaults to 0
+//	cursor      next_cursor of the previous page, empty for the first one. Can't be combined with offset
+//	fields      comma-separated mover fields to return, e.g. id,name,rating. Omitted returns every field
+type listOptions struct {
+	Name      string
+	Fuzzy     bool
+	MinRating *float64
+	MinRate   *float64
+	MaxRate   *float64
+	MinJobs   *int
+	MaxJobs   *int
+	Featured  *bool
+	Verified  *bool
+	Services  []string
+	Sort      []sortKey
+	Limit     int
+	Offset    int
+	// OffsetPaging is set when limit or offset is present without a cursor, CursorPaging when
+	// a cursor param is present. Cursor is nil on the first page
+	OffsetPaging bool
+	CursorPaging bool
+	Cursor       *listCursor
+	Fields       fieldMask
+}
+
+// pageLinks are the URLs of the neighbouring pages in offset pagination, nil when there is none
+type pageLinks struct {
+	Next *string `json:"next"`
+	Prev *string `json:"prev"`
+}
+
+// offsetPage is the envelope of a GET /movers page in offset pagination.
+// Movers holds the movers limited to the requested fields
+type offsetPage struct {
+	Movers any       `json:"movers"`
+	Total  int       `json:"total"`
+	Limit  int       `json:"limit"`
+	Offset int       `json:"offset"`
+	Links  pageLinks `json:"links"`
+}
+
+type sortKey struct {
+	Field string
+	Desc  bool
+}
+
+// queryParamErrors collects every invalid query param so the client gets all of them in one 400
+type queryParamErrors []string
+
+func (errs queryParamErrors) Error() string {
+	return strings.Join(errs, "; ")
+}
+
+// parseListOptions validates all list query params up front
+func parseListOptions(context *gin.Context) (listOptions, error) {
+	var errs queryParamErrors
+	options := listOptions{Name: strings.TrimSpace(context.Query("name"))}
+
+	parseFloat := func(name string) *float64 {
+		value, present, err := parseFloatQuery(context, name)
+		if err != nil {
+			errs = append(errs, err.Error())
+			return nil
+		}
+		if !present {
+			return nil
+		}
+		return &value
+	}
+	options.MinRating = parseFloat("min_rating")
+	options.MinRate = parseFloat("min_rate")
+	options.MaxRate = parseFloat("max_rate")
+
+	parseJobs := func(name string) *int {
+		param, present := context.GetQuery(name)
+		if !present {
+			return nil
+		}
+		jobs, err := strconv.Atoi(param)
+		if err != nil || jobs < 0 {
+			errs = append(errs, fmt.Sprintf("%s should be a non-negative integer", name))
+			return nil
+		}
+		return &jobs
+	}
+	options.MinJobs = parseJobs("min_jobs")
+	options.MaxJobs = parseJobs("max_jobs")
+
+	parseFlag := func(name string) *bool {
+		param, present := context.GetQuery(name)
+		if !present {
+			return nil
+		}
+		flag, err := strconv.ParseBool(param)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s should be true or false", name))
+			return nil
+		}
+		return &flag
+	}
+	options.Featured = parseFlag("featured")
+	options.Verified = parseFlag("verified")
+
+	if fuzzyParam, present := context.GetQuery("fuzzy"); present {
+		fuzzy, err := strconv.ParseBool(fuzzyParam)
+		if err != nil {
+			errs = append(errs, "fuzzy should be true or false")
+		} else if fuzzy && options.Name == "" {
+			errs = append(errs, "fuzzy needs a name to match")
+		} else {
+			options.Fuzzy = fuzzy
+		}
+	}
+
+	services, err := normalizeServices(context.QueryArray("service"))
+	if err != nil {
+		errs = append(errs, err.Error())
+	}
+	options.Services = services
+
+	if options.MinRating != nil && (*options.MinRating < 0.0 || *options.MinRating > 5.0) {
+		errs = append(errs, "min_rating should be in range between 0 and 5")
+	}
+	if options.MinRate != nil && options.MaxRate != nil && *options.MinRate > *options.MaxRate {
+		errs = append(errs, "min_rate should not be greater than max_rate")
+	}
+	if options.MinJobs != nil && options.MaxJobs != nil && *options.MinJobs > *options.MaxJobs {
+		errs = append(errs, "min_jobs should not be greater than max_jobs")
+	}
+
+	sortParam, hasSort := context.GetQuery("sort")
+	if !hasSort {
+		sortParam = defaultSortKey
+	}
+	for _, param := range strings.Split(sortParam, ",") {
+		param = strings.TrimSpace(param)
+		key := sortKey{Field: strings.TrimPrefix(param, "-"), Desc: strings.HasPrefix(param, "-")}
+		if key.Field == "match" && !options.Fuzzy {
+			errs = append(errs, "sort field \"match\" needs fuzzy=true")
+			continue
+		}
+		if _, ok := moverSortFields[key.Field]; !ok && key.Field != "rank" && key.Field != "match" {
+			errs = append(errs, fmt.Sprintf("sort field %q is not supported", key.Field))
+			continue
+		}
+		options.Sort = append(options.Sort, key)
+	}
+	if options.Fuzzy && !hasSort {
+		options.Sort = fuzzySortKeys
+	}
+
+	if limitParam, present := context.GetQuery("limit"); present {
+		limit, err := strconv.Atoi(limitParam)
+		if err != nil || limit < 1 || limit > maxListLimit {
+			errs = append(errs, fmt.Sprintf("limit should be an integer between 1 and %d", maxListLimit))
+		} else {
+			options.Limit = limit
+		}
+	}
+	if offsetParam, present := context.GetQuery("offset"); present {
+		offset, err := strconv.Atoi(offsetParam)
+		if err != nil || offset < 0 {
+			errs = append(errs, "offset should be a non-negative integer")
+		} else {
+			options.Offset = offset
+		}
+	}
+
+	_, hasLimit := context.GetQuery("limit")
+	_, hasOffset := context.GetQuery("offset")
+	options.OffsetPaging = hasLimit || hasOffset
+	if token, present := context.GetQuery("cursor"); present {
+		options.OffsetPaging = false
+		options.CursorPaging = true
+		if options.Limit == 0 {
+			options.Limit = defaultCursorSize
+		}
+		if hasOffset {
+			errs = append(errs, "cursor and offset can't be combined")
+		}
+		if token != "" {
+			cursor, err := decodeListCursor(token)
+			if err != nil {
+				errs = append(errs, err.Error())
+			} else if cursor.Sort != options.sortSpec() {
+				errs = append(errs, "cursor was issued for a different sort")
+			} else {
+				options.Cursor = &cursor
+			}
+		}
+	}
+
+	fieldsParam, hasFields := context.GetQuery("fields")
+	options.Fields, err = parseFieldMask(fieldsParam, hasFields)
+	if err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	if len(errs) > 0 {
+		return listOptions{}, errs
+	}
+	return options, nil
+}
+
+// sortSpec renders the sort keys back to the sort param, e.g. -rating,name
+func (options listOptions) sortSpec() string {
+	keys := make([]string, len(options.Sort))
+	for i, key := range options.Sort {
+		keys[i] = key.Field
+		if key.Desc {
+			keys[i] = "-" + key.Field
+		}
+	}
+	return strings.Join(keys, ",")
+}
+
+func (options listOptions) matches(m mover) bool {
+	if options.Fuzzy {
+		if nameDistance(m.Name, options.Name) > maxNameDistance(options.Name) {
+			return false
+		}
+	} else if options.Name != "" && !strings.Contains(strings.ToLower(m.Name), strings.ToLower(options.Name)) {
+		return false
+	}
+	if options.MinRating != nil && m.Rating < *options.MinRating {
+		return false
+	}
+	if options.MinRate != nil && m.HourlyRate < *options.MinRate {
+		return false
+	}
+	if options.MaxRate != nil && m.HourlyRate > *options.MaxRate {
+		return false
+	}
+	if options.MinJobs != nil && m.JobsAmount < *options.MinJobs {
+		return false
+	}
+	if options.MaxJobs != nil && m.JobsAmount > *options.MaxJobs {
+		return false
+	}
+	if options.Featured != nil && m.Featured != *options.Featured {
+		return false
+	}
+	if options.Verified != nil && m.Verified != *options.Verified {
+		return false
+	}
+	for _, service := range options.Services {
+		if !slices.Contains(m.Services, service) {
+			return false
+		}
+	}
+	return true
+}
+
+// compare orders two movers by the sort keys, scoreA and scoreB being their ranking scores.
+// IDs always break ties so the order is deterministic
+func (options listOptions) compare(a, b mover, scoreA, scoreB float64) int {
+	for _, key := range options.Sort {
+		var result int
+		if key.Field == "rank" {
+			// Best ranked first, featured movers ahead of everyone
+			result = rankOrder(a.Featured, scoreA, b.Featured, scoreB)
+		} else if key.Field == "match" {
+			// Closest fuzzy match first
+			result = cmp.Compare(nameDistance(a.Name, options.Name), nameDistance(b.Name, options.Name))
+		} else {
+			result = moverSortFields[key.Field](a, b)
+		}
+		if key.Desc {
+			result = -result
+		}
+		if result != 0 {
+			return result
+		}
+	}
+	return compareIdentity(a, b)
+}
+
+// sortedByRank reports whether the list is in plain rank order, the order of sortMoversByRank
+func (options listOptions) sortedByRank() bool {
+	return len(options.Sort) == 1 && options.Sort[0] == sortKey{Field: "rank"}
+}
+
+// apply filters, sorts and paginates the movers. total counts the matching movers,
+// in cursor pagination only those after the cursor. presorted movers are already in the requested order
+func (options listOptions) apply(movers []mover, r ranker, presorted bool) (page []mover, total int) {
+	filtered := []mover{}
+	for _, mover := range movers {
+		if !options.matches(mover) {
+			continue
+		}
+		// Movers up to the last one the client saw were on earlier pages
+		if options.Cursor != nil && options.compare(mover, options.Cursor.mover(), r.score(mover), options.Cursor.Score) <= 0 {
+			continue
+		}
+		filtered = append(filtered, mover)
+	}
+
+	// filtered is a new slice, presorted input stays sorted through filtering
+	sorted := filtered
+	if !presorted {
+		slices.SortStableFunc(sorted, func(a, b mover) int {
+			return options.compare(a, b, r.score(a), r.score(b))
+		})
+	}
+
+	total = len(sorted)
+	if options.Offset >= len(sorted) {
+		return []mover{}, total
+	}
+	sorted = sorted[options.Offset:]
+	if options.Limit > 0 && options.Limit < len(sorted) {
+		sorted = sorted[:options.Limit]
+	}
+	return sorted, total
+}
+
+// links builds the next and prev URLs of an offset page from the request URL, keeping every other
+// query param so paging through a filtered list keeps the filter
+func (options listOptions) links(requestURL *url.URL, total int) pageLinks {
+	linkTo := func(offset int) *string {
+		query := requestURL.Query()
+		query.Set("offset", strconv.Itoa(offset))
+		link := requestURL.Path + "?" + query.Encode()
+		return &link
+	}
+
+	var links pageLinks
+	if options.Limit > 0 && options.Offset+options.Limit < total {
+		links.Next = linkTo(options.Offset + options.Limit)
+	}
+	// Without a limit the previous page is everything before the offset, from the start
+	if options.Offset > 0 && options.Limit > 0 {
+		links.Prev = linkTo(max(options.Offset-options.Limit, 0))
+	} else if options.Offset > 0 {
+		links.Prev = linkTo(0)
+	}
+	return links
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRateAndRatingFilters(t *testing.T) {
+	router := newTestRouter(t)
+	cases := []struct {
+		query   string
+		matches func(m mover) bool
+	}{
+		{"max_rate=120", func(m mover) bool { return m.HourlyRate <= 120 }},
+		{"min_rate=140", func(m mover) bool { return m.HourlyRate >= 140 }},
+		{"min_rate=95&max_rate=110", func(m mover) bool { return m.HourlyRate >= 95 && m.HourlyRate <= 110 }},
+		{"min_rate=129.99&max_rate=129.99", func(m mover) bool { return m.HourlyRate == 129.99 }},
+		// Under $120/hr rated 4.5+
+		{"max_rate=120&min_rating=4.5", func(m mover) bool { return m.HourlyRate <= 120 && m.Rating >= 4.5 }},
+		{"min_rate=100&max_rate=150&min_rating=4.6", func(m mover) bool {
+			return m.HourlyRate >= 100 && m.HourlyRate <= 150 && m.Rating >= 4.6
+		}},
+		{"max_rate=50", func(m mover) bool { return false }},
+	}
+	for _, tc := range cases {
+		t.Run(tc.query, func(t *testing.T) {
+			want := []int{}
+			for _, m := range defaultMovers() {
+				if tc.matches(m) {
+					want = append(want, m.ID)
+				}
+			}
+			got := rankedIds(t, router, "/v1/movers?sort=id&"+tc.query)
+			if !slices.Equal(got, want) {
+				t.Errorf("got movers %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+// Every invalid param is reported in the same 400
+func TestInvalidFiltersAreAllReported(t *testing.T) {
+	router := newTestRouter(t)
+	cases := []struct {
+		query string
+		want  []string
+	}{
+		{"min_rate=cheap&max_rate=120&min_rating=9", []string{"min_rate should be a number", "min_rating should be in range between 0 and 5"}},
+		{"min_rate=150&max_rate=100&min_rating=high", []string{"min_rating should be a number", "min_rate should not be greater than max_rate"}},
+		{"max_rate=&min_jobs=-1&sort=price", []string{"max_rate should be a number", "min_jobs should be a non-negative integer", `sort field "price" is not supported`}},
+		{"min_rating=NaN&min_rate=-Inf&max_rate=Inf", []string{"min_rating should be a number", "min_rate should be a number", "max_rate should be a number"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.query, func(t *testing.T) {
+			recorder := doRequest(router, http.MethodGet, "/v1/movers?"+tc.query, "")
+			expectStatus(t, recorder, http.StatusBadRequest)
+			details := decode[struct{ Details []string }](t, recorder).Details
+			for _, message := range tc.want {
+				if !slices.Contains(details, message) {
+					t.Errorf("details %q miss %q", details, message)
+				}
+			}
+			if len(details) != len(tc.want) {
+				t.Errorf("got %d errors, want %d: %s", len(details), len(tc.want), strings.Join(details, "; "))
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// Log formats selectable with LOG_FORMAT
+const (
+	textLogFormat = "text"
+	jsonLogFormat = "json"
+)
+
+// parseLogLevel accepts debug, info, warn or error, case-insensitive
+func parseLogLevel(value string) (slog.Level, error) {
+	switch strings.ToLower(value) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "warn":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf("should be debug, info, warn or error, got %q", value)
+}
+
+// newLogger builds the service logger writing records of at least the configured level to output
+func newLogger(config Config, output io.Writer) *slog.Logger {
+	options := &slog.HandlerOptions{Level: config.LogLevel}
+	if config.LogFormat == jsonLogFormat {
+		return slog.New(slog.NewJSONHandler(output, options))
+	}
+	return slog.New(slog.NewTextHandler(output, options))
+}
+
+// requestLogger replaces Gin's text logger with one structured record per request. Successful
+// requests are logged at info, client errors at warn and server errors at error, so with
+// LOG_LEVEL=warn only failing requests are logged
+func requestLogger(logger *slog.Logger) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		start := time.Now()
+		context.Next()
+
+		status := context.Writer.Status()
+		level := slog.LevelInfo
+		if status >= 500 {
+			level = slog.LevelError
+		} else if status >= 400 {
+			level = slog.LevelWarn
+		}
+		if !logger.Enabled(context.Request.Context(), level) {
+			return
+		}
+
+		logger.LogAttrs(context.Request.Context(), level, "Request",
+			slog.String("method", context.Request.Method),
+			slog.String("path", context.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("client_ip", context.ClientIP()),
+			slog.Int("bytes", context.Writer.Size()),
+		)
+	}
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"bytes"
+	"encoding/csv"
 	"encoding/json"
 	"errors"
 	_ "errors"
@@ -9,239 +11,1802 @@ import (
 	_ "github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 	_ "github.com/joho/godotenv"
+	"io"
 	"log"
+	"log/slog"
 	"math"
 	"net/http"
 	_ "net/http"
 	"os"
 	_ "os"
+	"regexp"
 	"slices"
 	"sort"
 	"strconv"
 	_ "strconv"
+	"strings"
+	"sync/atomic"
+	"time"
 )
 
 // Struct represents our mover model:
 type mover struct {
-	ID              int     `json:"id"`
-	Name            string  `json:"name"`
-	Rating          float64 `json:"rating"`
-	TelephoneNumber string  `json:"telephone_number"`
-	JobsAmount      int     `json:"jobs_done"`
+	ID              int                  `json:"id" form:"-"`
+	Name            string               `json:"name" form:"name" binding:"required,notblank,max=100,nocontrol"`
+	Rating          float64              `json:"rating" form:"rating" binding:"finite,gte=0,lte=5"`
+	TelephoneNumber string               `json:"telephone_number" form:"telephone_number" binding:"required,telephone"`
+	JobsAmount      int                  `json:"jobs_done" form:"jobs_done" binding:"gte=0"`
+	ReviewCount     int                  `json:"review_count" form:"-"` // Only counted up by reviews, a client-set count would outweigh the Bayesian prior
+	Latitude        float64              `json:"latitude" form:"latitude" binding:"finite,gte=-90,lte=90"`
+	Longitude       float64              `json:"longitude" form:"longitude" binding:"finite,gte=-180,lte=180"`
+	HourlyRate      float64              `json:"hourly_rate" form:"hourly_rate" binding:"finite,gte=0"`
+	Services        []string             `json:"services" form:"services"` // Repeated field in forms
+	Availability    []availabilityWindow `json:"availability" form:"-"`    // JSON only, forms can't nest objects
+	Featured        bool                 `json:"featured" form:"-"`        // Sponsored partner ranked ahead of everyone else, only set through the admin feature endpoints
+	Verified        bool                 `json:"verified" form:"-"`        // Identity and license confirmed, only set through the admin verify endpoints
+	// Mean minutes to respond to an inquiry, recorded through POST /movers/:id/response-time.
+	// 0 until the first sample
+	AvgResponseMinutes float64   `json:"avg_response_minutes" form:"-"`
+	ResponseSamples    int       `json:"response_samples" form:"-"`
+	CreatedAt          time.Time `json:"created_at" form:"-"` // RFC3339, set once when the mover is added
+	UpdatedAt          time.Time `json:"updated_at" form:"-"` // RFC3339, bumped on every change
+	Deleted            bool      `json:"-" form:"-"`          // Soft-delete marker, deleted movers are hidden but kept for restore
+	// Running totals behind Rating, see addRating. Zero until the first review is added
+	RatingSum    float64 `json:"-" form:"-"`
+	RatingWeight float64 `json:"-" form:"-"`
+	// Part of the aggregate without stored reviews behind it, e.g. the seed ratings, see setRatingBaseline
+	BaselineRatingSum    float64 `json:"-" form:"-"`
+	BaselineRatingWeight float64 `json:"-" form:"-"`
+	BaselineReviewCount  int     `json:"-" form:"-"`
+	// Running total behind AvgResponseMinutes, see addResponseSample
+	ResponseMinutesSum float64 `json:"-" form:"-"`
 }
 
-// MarshalJSON Custom MarshalJSON to round the Rating field in JSON output only
+// MarshalJSON Custom MarshalJSON to round the HourlyRate field in JSON output only.
+// Ratings are rounded by the handlers to the precision of the request, see ratingPrecision
 func (m mover) MarshalJSON() ([]byte, error) {
-	type Alias mover                        // Alias to prevent recursion in MarshalJSON
-	m.Rating = math.Round(m.Rating*10) / 10 // Round Rating to 1 decimal place for JSON output
+	type Alias mover                                  // Alias to prevent recursion in MarshalJSON
+	m.HourlyRate = math.Round(m.HourlyRate*100) / 100 // Round HourlyRate to cents for JSON output
+	m.AvgResponseMinutes = math.Round(m.AvgResponseMinutes*10) / 10
 	return json.Marshal((Alias)(m))
 }
 
-// Database of movers:
-var movers = []mover{
-	{ID: 1, Name: "San Francisco MOV", Rating: 4.6, TelephoneNumber: "+15615557689", JobsAmount: 3780},
-	{ID: 2, Name: "Rapid Movers", Rating: 4.2, TelephoneNumber: "+15617384568", JobsAmount: 1240},
-	{ID: 3, Name: "Reliable Relocations", Rating: 4.7, TelephoneNumber: "+14155538692", JobsAmount: 2050},
-	{ID: 4, Name: "City Express Movers", Rating: 4.5, TelephoneNumber: "+18025559482", JobsAmount: 1870},
-	{ID: 5, Name: "Pro Mover Co.", Rating: 4.8, TelephoneNumber: "+17024457893", JobsAmount: 2500},
-	{ID: 6, Name: "MoveOn Solutions", Rating: 4.4, TelephoneNumber: "+19025548765", JobsAmount: 1730},
-	{ID: 7, Name: "All Star Moving", Rating: 4.3, TelephoneNumber: "+13125587612", JobsAmount: 1290},
-	{ID: 8, Name: "Swift Relocation", Rating: 4.6, TelephoneNumber: "+12026758741", JobsAmount: 3100},
-	{ID: 9, Name: "Speedy Transport", Rating: 4.5, TelephoneNumber: "+14027759832", JobsAmount: 1980},
-	{ID: 10, Name: "Premier Movers", Rating: 4.7, TelephoneNumber: "+15022556478", JobsAmount: 2300},
-	{ID: 11, Name: "Ace Relocators", Rating: 4.3, TelephoneNumber: "+16024457812", JobsAmount: 1670},
-	{ID: 12, Name: "Trusted Movers Co.", Rating: 4.6, TelephoneNumber: "+17024459874", JobsAmount: 2890},
-	{ID: 13, Name: "Urban Move", Rating: 4.5, TelephoneNumber: "+18024458736", JobsAmount: 3200},
-	{ID: 14, Name: "FastTrack Movers", Rating: 4.7, TelephoneNumber: "+13027758495", JobsAmount: 2150},
-	{ID: 15, Name: "Metro Moving Solutions", Rating: 4.4, TelephoneNumber: "+14028854721", JobsAmount: 1390},
+// now is the timestamp stored on mutations, truncated to whole seconds for RFC3339 output
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Second)
 }
 
+// Sample weekly schedules for the seed movers
+var (
+	standardHours = availabilityOn("08:00", "18:00", workweek...)
+	officeHours   = availabilityOn("09:00", "17:00", workweek...)
+	extendedHours = append(availabilityOn("07:00", "19:00", workweek...), availabilityOn("09:00", "15:00", "saturday")...)
+	everyDayHours = availabilityOn("08:00", "20:00", "sunday", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday")
+)
+
+// Timestamp of the seed movers, which predate created_at tracking
+var seededAt = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// defaultMovers returns a fresh copy of the seed movers the service starts with
+func defaultMovers() []mover {
+	return []mover{
+		{ID: 1, Name: "San Francisco MOV", Rating: 4.6, TelephoneNumber: "+15615557689", JobsAmount: 3780, ReviewCount: 912, Latitude: 37.7749, Longitude: -122.4194, HourlyRate: 135, Services: []string{"local", "long-distance", "packing"}, Availability: extendedHours, CreatedAt: seededAt, UpdatedAt: seededAt},
+		{ID: 2, Name: "Rapid Movers", Rating: 4.2, TelephoneNumber: "+15617384568", JobsAmount: 1240, ReviewCount: 418, Latitude: 26.3683, Longitude: -80.1289, HourlyRate: 95, Services: []string{"local", "apartment"}, Availability: officeHours, CreatedAt: seededAt, UpdatedAt: seededAt},
+		{ID: 3, Name: "Reliable Relocations", Rating: 4.7, TelephoneNumber: "+14155538692", JobsAmount: 2050, ReviewCount: 731, Latitude: 37.7849, Longitude: -122.4094, HourlyRate: 150, Services: []string{"long-distance", "piano", "packing"}, Availability: standardHours, CreatedAt: seededAt, UpdatedAt: seededAt},
+		{ID: 4, Name: "City Express Movers", Rating: 4.5, TelephoneNumber: "+18025559482", JobsAmount: 1870, ReviewCount: 402, Latitude: 44.4759, Longitude: -73.2121, HourlyRate: 110, Services: []string{"local", "apartment", "storage"}, Availability: standardHours, CreatedAt: seededAt, UpdatedAt: seededAt},
+		{ID: 5, Name: "Pro Mover Co.", Rating: 4.8, TelephoneNumber: "+17024457893", JobsAmount: 2500, ReviewCount: 1064, Latitude: 36.1699, Longitude: -115.1398, HourlyRate: 165, Services: []string{"long-distance", "piano", "commercial"}, Availability: everyDayHours, CreatedAt: seededAt, UpdatedAt: seededAt},
+		{ID: 6, Name: "MoveOn Solutions", Rating: 4.4, TelephoneNumber: "+19025548765", JobsAmount: 1730, ReviewCount: 289, Latitude: 44.6488, Longitude: -63.5752, HourlyRate: 99.5, Services: []string{"local", "packing"}, Availability: officeHours, CreatedAt: seededAt, UpdatedAt: seededAt},
+		{ID: 7, Name: "All Star Moving", Rating: 4.3, TelephoneNumber: "+13125587612", JobsAmount: 1290, ReviewCount: 337, Latitude: 41.8781, Longitude: -87.6298, HourlyRate: 89, Services: []string{"local", "apartment", "piano"}, Availability: standardHours, CreatedAt: seededAt, UpdatedAt: seededAt},
+		{ID: 8, Name: "Swift Relocation", Rating: 4.6, TelephoneNumber: "+12026758741", JobsAmount: 3100, ReviewCount: 845, Latitude: 38.9072, Longitude: -77.0369, HourlyRate: 140, Services: []string{"long-distance", "storage"}, Availability: extendedHours, CreatedAt: seededAt, UpdatedAt: seededAt},
+		{ID: 9, Name: "Speedy Transport", Rating: 4.5, TelephoneNumber: "+14027759832", JobsAmount: 1980, ReviewCount: 520, Latitude: 41.2565, Longitude: -95.9345, HourlyRate: 105, Services: []string{"local", "commercial"}, Availability: standardHours, CreatedAt: seededAt, UpdatedAt: seededAt},
+		{ID: 10, Name: "Premier Movers", Rating: 4.7, TelephoneNumber: "+15022556478", JobsAmount: 2300, ReviewCount: 693, Latitude: 38.2527, Longitude: -85.7585, HourlyRate: 155, Services: []string{"long-distance", "piano", "packing", "storage"}, Availability: extendedHours, CreatedAt: seededAt, UpdatedAt: seededAt},
+		{ID: 11, Name: "Ace Relocators", Rating: 4.3, TelephoneNumber: "+16024457812", JobsAmount: 1670, ReviewCount: 251, Latitude: 33.4484, Longitude: -112.074, HourlyRate: 92, Services: []string{"local", "apartment"}, Availability: officeHours, CreatedAt: seededAt, UpdatedAt: seededAt},
+		{ID: 12, Name: "Trusted Movers Co.", Rating: 4.6, TelephoneNumber: "+17024459874", JobsAmount: 2890, ReviewCount: 1012, Latitude: 36.0395, Longitude: -114.9817, HourlyRate: 129.99, Services: []string{"long-distance", "commercial", "packing"}, Availability: everyDayHours, CreatedAt: seededAt, UpdatedAt: seededAt},
+		{ID: 13, Name: "Urban Move", Rating: 4.5, TelephoneNumber: "+18024458736", JobsAmount: 3200, ReviewCount: 604, Latitude: 44.2601, Longitude: -72.5754, HourlyRate: 118, Services: []string{"local", "apartment", "storage"}, Availability: standardHours, CreatedAt: seededAt, UpdatedAt: seededAt},
+		{ID: 14, Name: "FastTrack Movers", Rating: 4.7, TelephoneNumber: "+13027758495", JobsAmount: 2150, ReviewCount: 788, Latitude: 39.7391, Longitude: -75.5398, HourlyRate: 145, Services: []string{"long-distance", "packing"}, Availability: extendedHours, CreatedAt: seededAt, UpdatedAt: seededAt},
+		{ID: 15, Name: "Metro Moving Solutions", Rating: 4.4, TelephoneNumber: "+14028854721", JobsAmount: 1390, ReviewCount: 366, Latitude: 40.8136, Longitude: -96.7026, HourlyRate: 97, Services: []string{"local", "commercial"}, Availability: officeHours, CreatedAt: seededAt, UpdatedAt: seededAt},
+	}
+}
+
+const apiVersionPrefix = "/v1"
+
+const maxServiceLength = 50
+
+const (
+	defaultTopMovers = 5
+	maxTopMovers     = 50
+)
+
+const (
+	defaultFeedLimit         = 5
+	maxFeedLimit             = 50
+	highQualityRatingMinimum = 4.5 // movers rated at least this count towards the feed's quality indicator
+)
+
+// Thresholds for the implausible stats report
+const (
+	implausibleRoundJobsMultiple = 100  // jobs_done that is an exact multiple of this looks hand-typed
+	implausibleBoundRatingCount  = 1000 // a perfect 0.0 or 5.0 rating across this many jobs is unlikely
+)
+
+const (
+	earthRadiusKm         = 6371.0
+	defaultNearbyRadiusKm = 50.0
+)
+
+// E.164 telephone format: leading +, country code and up to 15 digits in total
+var telNumberPattern = regexp.MustCompile(`^\+[1-9]\d{1,14}$`)
+
 // Helper functions
+// normalizeTelNumber strips the formatting characters people usually type in phone numbers
+func normalizeTelNumber(telNumber string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '(', ')', '.':
+			return -1
+		}
+		return r
+	}, telNumber)
+}
+
+// normalizeStoredMovers trims names, normalizes telephone numbers and clamps ratings of every
+// stored mover, deleted ones too, logging every correction. The corrections are saved in one unit,
+// so they survive a restart. Returns the number of corrected movers
+func normalizeStoredMovers(store MoverStore) (int, error) {
+	corrected := 0
+	err := store.Atomically(func() error {
+		corrected = 0
+		for _, m := range store.All() {
+			rating := m.Rating
+			if !normalizeLoadedMover(&m) {
+				continue
+			}
+			if m.Rating != rating {
+				// The totals behind the out-of-range rating restart from the clamped one
+				m.RatingSum, m.RatingWeight = 0, 0
+				m.setRatingBaseline(store.Reviews(m.ID))
+			}
+			if err := store.Update(m); err != nil {
+				return err
+			}
+			corrected++
+		}
+		return nil
+	})
+	return corrected, err
+}
+
+// normalizeLoadedMover normalizes one mover in place and reports whether anything changed
+func normalizeLoadedMover(m *mover) bool {
+	original := *m
+	m.Name = strings.TrimSpace(m.Name)
+	m.TelephoneNumber = normalizeTelNumber(m.TelephoneNumber)
+	m.Rating = math.Max(0.0, math.Min(5.0, m.Rating))
+
+	changed := false
+	if m.Name != original.Name {
+		slog.Info("Normalized mover name", "id", original.ID, "from", original.Name, "to", m.Name)
+		changed = true
+	}
+	if m.TelephoneNumber != original.TelephoneNumber {
+		slog.Info("Normalized mover telephone number", "id", original.ID, "from", original.TelephoneNumber, "to", m.TelephoneNumber)
+		changed = true
+	}
+	if m.Rating != original.Rating {
+		slog.Info("Normalized mover rating", "id", original.ID, "from", original.Rating, "to", m.Rating)
+		changed = true
+	}
+	return changed
+}
+
+// checkMoverConflicts reports every duplicate ID, name and telephone number among the loaded movers,
+// the handlers assume all three are unique. Names are compared case-insensitively and numbers normalized
+func checkMoverConflicts(movers []mover) error {
+	conflicts := []string{}
+	ids := map[int]mover{}
+	names := map[string]mover{}
+	telNumbers := map[string]mover{}
+	for _, m := range movers {
+		if first, found := ids[m.ID]; found {
+			conflicts = append(conflicts, fmt.Sprintf("ID %d is used by %q and %q", m.ID, first.Name, m.Name))
+		} else {
+			ids[m.ID] = m
+		}
+		if first, found := names[canonicalName(m.Name)]; found {
+			conflicts = append(conflicts, fmt.Sprintf("name %q is used by movers %d and %d", m.Name, first.ID, m.ID))
+		} else {
+			names[canonicalName(m.Name)] = m
+		}
+		telNumber := normalizeTelNumber(m.TelephoneNumber)
+		if first, found := telNumbers[telNumber]; found {
+			conflicts = append(conflicts, fmt.Sprintf("telephone number %s is used by movers %d and %d", telNumber, first.ID, m.ID))
+		} else {
+			telNumbers[telNumber] = m
+		}
+	}
+	if len(conflicts) > 0 {
+		return errors.New(strings.Join(conflicts, "; "))
+	}
+	return nil
+}
+
+func buildTelNumberIndex(movers []mover) map[string]int {
+	index := make(map[string]int, len(movers))
+	for _, mover := range movers {
+		index[normalizeTelNumber(mover.TelephoneNumber)] = mover.ID
+	}
+	return index
+}
+
+// parseFloatQuery returns the query param as float, present is false when the param is omitted.
+// NaN and Inf are rejected, a filter on them would silently match nothing
+func parseFloatQuery(context *gin.Context, name string) (value float64, present bool, err error) {
+	param, present := context.GetQuery(name)
+	if !present {
+		return 0, false, nil
+	}
+	value, err = strconv.ParseFloat(param, 64)
+	if err != nil || !isFinite(value) {
+		return 0, true, fmt.Errorf("%s should be a number", name)
+	}
+	return value, true, nil
+}
+
+// extractId parses the :id path param. Callers respond with 400 when it isn't a number
 func extractId(context *gin.Context) (int, error) {
 	idParam := context.Param("id")
 	MoverId, err := strconv.Atoi(idParam)
 	if err != nil {
-		context.JSON(http.StatusBadRequest, gin.H{"message": "Conversion error"})
 		return -1, err
 	} else {
 		return MoverId, nil
 	}
 }
 
-func getMoverById(id int) (*mover, error) {
-	for i, mover := range movers {
+// moverRank returns the 1-based position of the mover in the sorted list
+func moverRank(sortedMovers []mover, id int) int {
+	for i, mover := range sortedMovers {
 		if mover.ID == id {
-			return &movers[i], nil
+			return i + 1
 		}
 	}
-	return nil, errors.New("mover not found")
+	return -1
 }
 
-func findMoverIndexById(id int) (int, error) {
-	for index, mover := range movers {
-		if mover.ID == id {
-			return index, nil
+// reviewRequest is the body of a review. Rating is a pointer so an omitted rating
+// is rejected instead of being recorded as a zero-star review. ReviewerID is optional
+type reviewRequest struct {
+	Rating     *float64 `json:"rating" form:"rating"`
+	ReviewerID string   `json:"reviewer_id" form:"reviewer_id"`
+	Weight     *float64 `json:"weight" form:"weight"` // job size factor, defaults to 1
+}
+
+// bindReview reads the review body, JSON or form, or returns an error message telling apart
+// a missing body, a malformed one, a missing rating and an out of range one.
+// A body over the size limit returns the *http.MaxBytesError for respondBindError
+func bindReview(context *gin.Context) (reviewRequest, error) {
+	var review reviewRequest
+	if err := bindBody(context, &review); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return reviewRequest{}, err
+		}
+		if errors.Is(err, io.EOF) || context.Request.ContentLength == 0 {
+			return reviewRequest{}, errors.New("Request body is required")
+		}
+		if isFormBody(context) {
+			return reviewRequest{}, errors.New("Malformed form data")
 		}
+		return reviewRequest{}, errors.New("Malformed JSON")
+	}
+	// An emptied form field is sent as an empty value, which binds to 0
+	if isFormBody(context) {
+		if strings.TrimSpace(context.PostForm("rating")) == "" {
+			review.Rating = nil
+		}
+		if strings.TrimSpace(context.PostForm("weight")) == "" {
+			review.Weight = nil
+		}
+	}
+	if review.Rating == nil {
+		return reviewRequest{}, errors.New("Rating is required")
 	}
-	return -1, errors.New("mover not found")
+	if !isFinite(*review.Rating) || *review.Rating < 0.0 || *review.Rating > 5.0 {
+		return reviewRequest{}, errors.New("Provided rate should be in range between 0 and 5")
+	}
+	if review.Weight == nil {
+		weight := defaultReviewWeight
+		review.Weight = &weight
+	}
+	if !isFinite(*review.Weight) || *review.Weight < minReviewWeight || *review.Weight > maxReviewWeight {
+		return reviewRequest{}, fmt.Errorf("Weight should be in range between %g and %g", minReviewWeight, maxReviewWeight)
+	}
+	review.ReviewerID = strings.TrimSpace(review.ReviewerID)
+	if containsControl(review.ReviewerID) {
+		return reviewRequest{}, errors.New("Reviewer ID should not contain control characters")
+	}
+	return review, nil
 }
 
-func deleteElement(slice []mover, index int) []mover {
-	return slices.Delete(slice, index, index+1)
+// addRating adds one review to the mover's running totals and derives Rating from them.
+// The totals are only ever added to, so the average stays exact however many reviews come in,
+// instead of drifting by re-averaging an already rounded average. Movers without totals yet
+// start them from the Rating and ReviewCount they came with, at the default weight per review
+func (m *mover) addRating(rate, weight float64) {
+	m.startRatingTotals()
+	m.RatingSum += rate * weight
+	m.RatingWeight += weight
+	m.ReviewCount += 1
+	m.Rating = m.RatingSum / m.RatingWeight
 }
 
-func sortMoversByRatingAndId(movers []mover) []mover {
-	moversCopy := make([]mover, len(movers))
-	copy(moversCopy, movers)
+// removeRating takes one review back out of the running totals. Without reviews left the rating is 0
+func (m *mover) removeRating(rate, weight float64) {
+	m.startRatingTotals()
+	m.RatingSum -= rate * weight
+	m.RatingWeight -= weight
+	m.ReviewCount = max(m.ReviewCount-1, 0)
+	if m.ReviewCount == 0 || m.RatingWeight <= ratingDriftTolerance {
+		m.Rating, m.RatingSum, m.RatingWeight = 0, 0, 0
+		return
+	}
+	m.Rating = m.RatingSum / m.RatingWeight
+}
 
-	sort.Slice(moversCopy, func(i, j int) bool {
-		if moversCopy[i].Rating == moversCopy[j].Rating {
-			return moversCopy[i].ID < moversCopy[j].ID
-		} else {
-			return moversCopy[i].Rating > moversCopy[j].Rating
+func (m *mover) startRatingTotals() {
+	if m.RatingWeight == 0 {
+		m.RatingWeight = float64(m.ReviewCount) * defaultReviewWeight
+		m.RatingSum = m.Rating * m.RatingWeight
+	}
+}
+
+// setRatingBaseline records the part of the mover's aggregate that the given stored reviews
+// don't explain, such as the seed ratings or a count set through PUT. recomputeRatings adds the
+// stored reviews to it, so it can't wipe an aggregate that never had individual reviews
+func (m *mover) setRatingBaseline(reviews []review) {
+	m.startRatingTotals()
+	sum, weight := m.RatingSum, m.RatingWeight
+	for _, review := range reviews {
+		sum -= review.Rating * review.Weight
+		weight -= review.Weight
+	}
+	m.BaselineReviewCount = max(m.ReviewCount-len(reviews), 0)
+	if m.BaselineReviewCount == 0 || weight <= ratingDriftTolerance {
+		m.BaselineReviewCount, m.BaselineRatingSum, m.BaselineRatingWeight = 0, 0, 0
+		return
+	}
+	m.BaselineRatingSum, m.BaselineRatingWeight = sum, weight
+}
+
+// reviewRatio returns reviews per job done, movers without jobs get 0 instead of dividing by zero
+func reviewRatio(m mover) float64 {
+	if m.JobsAmount <= 0 {
+		return 0
+	}
+	return float64(m.ReviewCount) / float64(m.JobsAmount)
+}
+
+// implausibilityReasons flags stats that look made up, or that a bad data file put out of range.
+// Movers are only reported, never rejected
+func implausibilityReasons(m mover) []string {
+	reasons := []string{}
+	if !isFinite(m.Rating) {
+		reasons = append(reasons, fmt.Sprintf("rating is %v", m.Rating))
+	} else if m.Rating < 0 || m.Rating > 5 {
+		reasons = append(reasons, fmt.Sprintf("rating %g is outside 0 to 5", m.Rating))
+	}
+	if m.JobsAmount > 0 && m.JobsAmount%implausibleRoundJobsMultiple == 0 {
+		reasons = append(reasons, fmt.Sprintf("jobs_done is an exact multiple of %d", implausibleRoundJobsMultiple))
+	}
+	if (m.Rating == 0.0 || m.Rating == 5.0) && m.JobsAmount >= implausibleBoundRatingCount {
+		reasons = append(reasons, fmt.Sprintf("rating is exactly %.1f across %d jobs", m.Rating, m.JobsAmount))
+	}
+	if m.ReviewCount > m.JobsAmount {
+		reasons = append(reasons, "review_count is higher than jobs_done")
+	}
+	return reasons
+}
+
+// normalizeServices trims, lowercases and de-duplicates services so filtering can match case-insensitively
+func normalizeServices(services []string) ([]string, error) {
+	normalized := []string{}
+	seen := make(map[string]bool, len(services))
+	for _, service := range services {
+		service = strings.ToLower(strings.TrimSpace(service))
+		if service == "" {
+			return nil, errors.New("services should not contain empty values")
 		}
-	})
-	return moversCopy
+		if len(service) > maxServiceLength {
+			return nil, fmt.Errorf("services should be at most %d characters long", maxServiceLength)
+		}
+		if !seen[service] {
+			seen[service] = true
+			normalized = append(normalized, service)
+		}
+	}
+	return normalized, nil
+}
+
+func validateCoordinates(latitude, longitude float64) error {
+	if !isFinite(latitude) || latitude < -90 || latitude > 90 {
+		return errors.New("latitude should be in range between -90 and 90")
+	}
+	if !isFinite(longitude) || longitude < -180 || longitude > 180 {
+		return errors.New("longitude should be in range between -180 and 180")
+	}
+	return nil
+}
+
+// haversineKm returns the great-circle distance between two points in kilometers
+func haversineKm(lat1, lng1, lat2, lng2 float64) float64 {
+	toRadians := func(degrees float64) float64 { return degrees * math.Pi / 180 }
+
+	dLat := toRadians(lat2 - lat1)
+	dLng := toRadians(lng2 - lng1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRadians(lat1))*math.Cos(toRadians(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
+
+// canonicalName is the form names are matched by, so "Rapid Movers", "rapid movers" and
+// "Rapid Movers " are the same company. The display name is kept as entered
+func canonicalName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+func sameName(a, b string) bool {
+	return strings.EqualFold(strings.TrimSpace(a), strings.TrimSpace(b))
 }
 
-func checkMoverExists(newMover mover) bool {
-	for _, existingMover := range movers {
-		if existingMover.ID == newMover.ID || existingMover.Name == newMover.Name {
-			return true
+// bulkNames tracks the names and tel. numbers accepted so far in one bulk import
+type bulkNames struct {
+	names      map[string]bool
+	telNumbers map[string]bool
+}
+
+func newBulkNames(size int) bulkNames {
+	return bulkNames{names: make(map[string]bool, size), telNumbers: make(map[string]bool, size)}
+}
+
+// validateBulkMover checks one entry against the existing movers and the accepted entries,
+// normalizing services and availability in place. A valid entry is accepted, so later duplicates of it fail
+func validateBulkMover(store MoverStore, accepted bulkNames, newMover *mover) error {
+	if err := validateFields(*newMover); err != nil {
+		return err
+	}
+	// Stored in canonical form so the index, lookups and exports agree
+	telNumber := normalizeTelNumber(newMover.TelephoneNumber)
+	services, err := normalizeServices(newMover.Services)
+	if err != nil {
+		return err
+	}
+	availability, err := normalizeAvailability(newMover.Availability)
+	if err != nil {
+		return err
+	}
+	if accepted.names[canonicalName(newMover.Name)] || store.NameTaken(newMover.Name, noExclusion) {
+		return errors.New("mover already exists")
+	}
+	if accepted.telNumbers[telNumber] || store.TelNumberTaken(telNumber, noExclusion) {
+		return errors.New("tel. number is occupied")
+	}
+	newMover.TelephoneNumber = telNumber
+	newMover.Services = services
+	newMover.Availability = availability
+	accepted.names[canonicalName(newMover.Name)] = true
+	accepted.telNumbers[telNumber] = true
+	return nil
+}
+
+// validateBulkMovers checks every entry against the existing movers and the rest of the batch.
+// Returns the index of the first offending entry and the reason, or -1 when the batch is valid
+func validateBulkMovers(store MoverStore, batch []mover) (int, error) {
+	accepted := newBulkNames(len(batch))
+	for i := range batch {
+		if err := validateBulkMover(store, accepted, &batch[i]); err != nil {
+			return i, err
 		}
 	}
-	return false
+	return -1, nil
+}
+
+// server holds the dependencies of the mover handlers
+type server struct {
+	config        Config
+	store         MoverStore // nil until serve is called, see requireReady
+	ready         atomic.Bool
+	readOnly      atomic.Bool // see writeLocked
+	webhook       *webhookNotifier
+	idempotency   *idempotencyCache
+	recentReviews *reviewDedupCache
+	events        *eventHub
+	rankCache     rankedCache
+}
+
+// ranker returns the ranker for the current movers with the configured prior weight, see rankedCache
+func (s *server) ranker() ranker {
+	r, _ := s.ranked()
+	return r
+}
+
+func (s *server) computeRanker() ranker {
+	if querying, ok := s.store.(queryingStore); ok {
+		return ranker{globalMean: querying.MeanRating(), priorWeight: s.config.BayesianPriorWeight}
+	}
+	return newRanker(s.store.List(), s.config.BayesianPriorWeight)
 }
 
-func checkMoverTelNumber(newMover mover) bool {
-	for _, existingMover := range movers {
-		if existingMover.TelephoneNumber == newMover.TelephoneNumber {
-			return true
+// readLocked runs a handler that only reads movers under the store's shared lock,
+// so it sees a consistent snapshot while writes are blocked
+func (s *server) readLocked(handler gin.HandlerFunc) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		if _, ok := bufferBody(context); !ok {
+			return
 		}
+		s.store.RLock()
+		defer s.store.RUnlock()
+		handler(context)
 	}
-	return false
 }
 
-func initializeRouter() *gin.Engine {
-	router := gin.Default()
+// writeLocked runs a handler that changes movers or reviews under the store's exclusive lock.
+// In read-only mode it answers 503 instead, every mutating route goes through here.
+// When a write failed only because the database was busy, the handler's changes are already
+// rolled back and it runs again, up to STORE_WRITE_RETRIES times with a doubling backoff.
+// The lock is released while backing off, so other requests go on in the meantime.
+// The failed response is only sent once the retries run out
+func (s *server) writeLocked(handler gin.HandlerFunc) gin.HandlerFunc {
+	locked := func(context *gin.Context) {
+		s.store.Lock()
+		defer s.store.Unlock()
+		defer s.rankCache.invalidate()
+		handler(context)
+	}
+	return func(context *gin.Context) {
+		if s.isReadOnly() {
+			context.JSON(http.StatusServiceUnavailable, readOnlyResponse)
+			return
+		}
+		body, ok := bufferBody(context)
+		if !ok {
+			return
+		}
 
-	router.GET("/movers", getMovers)
-	router.POST("/movers", addMover)
-	router.DELETE("/movers/:id", deleteMover)
-	router.POST("/movers/:id/review", recommendMover)
+		original := context.Writer
+		backoff := storeRetryBackoff
+		for attempt := 0; ; attempt++ {
+			buffered := &bufferedWriter{ResponseWriter: original}
+			context.Writer = buffered
+			context.Request.Body = io.NopCloser(bytes.NewReader(body))
+			context.Errors = context.Errors[:0]
+			locked(context)
+			context.Writer = original
 
-	return router
+			failure := context.Errors.Last()
+			if failure == nil || !isTransient(failure.Err) || attempt == s.config.StoreWriteRetries {
+				_, _ = original.Write(buffered.body.Bytes())
+				return
+			}
+			slog.Warn("Retrying a write the database was too busy for", "attempt", attempt+1, "backoff", backoff, "error", failure.Err)
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
 }
 
-// Main Functions
-// GET request. Sort by Rating. If rates are equal, sort by ID
-func getMovers(context *gin.Context) {
-	if len(movers) == 0 {
-		context.JSON(http.StatusNotFound, gin.H{"error": "movers list is empty"})
-		return
+// respondStoreError answers a failed store write. Movers and reviews that are gone are a 404,
+// anything else is a storage failure the client can't fix. The error is recorded on the
+// context, writeLocked retries the request when the database was only busy
+func respondStoreError(context *gin.Context, err error) {
+	_ = context.Error(err)
+	switch {
+	case errors.Is(err, errMoverNotFound):
+		context.JSON(http.StatusNotFound, gin.H{"message": "Mover not found"})
+	case errors.Is(err, errReviewNotFound):
+		context.JSON(http.StatusNotFound, gin.H{"message": "Review not found"})
+	default:
+		slog.Error("Store write failed", "method", context.Request.Method, "path", context.Request.URL.Path, "error", err)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": "Could not save the changes, try again later"})
 	}
+}
 
-	sortedMovers := sortMoversByRatingAndId(movers)
+// registerMoverRoutes registers the versioned API routes on a router group
+func (s *server) registerMoverRoutes(routes gin.IRoutes) {
+	routes.Use(precisionMiddleware(s.config.RatingPrecision))
 
-	context.JSON(http.StatusOK, sortedMovers)
+	routes.GET("/movers", s.readLocked(s.getMovers))
+	routes.GET("/movers.csv", s.readLocked(s.exportMoversCSV))
+	routes.GET("/movers/most-reviewed-relative", s.readLocked(s.getMostReviewedRelative))
+	routes.GET("/movers/nearby", s.readLocked(s.getNearbyMovers))
+	routes.GET("/movers/available", s.readLocked(s.getAvailableMovers))
+	routes.GET("/movers/recommend/feed", s.readLocked(s.getRecommendationFeed))
+	routes.GET("/movers/top", s.readLocked(s.getTopMovers))
+	routes.GET("/movers/stats", s.readLocked(s.getMoverStats))
+	routes.GET("/movers/compare", s.readLocked(s.compareMovers))
+	routes.GET("/movers/random", s.readLocked(s.getRandomMovers))
+	routes.GET("/movers/events", s.streamMoverEvents)
+	routes.GET("/movers/by-phone/:number", s.readLocked(s.getMoverByTelNumber))
+	routes.POST("/movers", s.writeLocked(s.idempotent(s.addMover)))
+	routes.POST("/movers/bulk", s.writeLocked(s.addMoversBulk))
+	routes.POST("/movers/recompute", s.adminOnly(s.writeLocked(s.recomputeRatings)))
+	routes.DELETE("/movers", s.writeLocked(s.deleteMoversBulk))
+	routes.GET("/movers/:id", s.readLocked(s.getMover))
+	routes.PUT("/movers/:id", s.writeLocked(s.replaceMover))
+	routes.PATCH("/movers/:id", s.writeLocked(s.patchMover))
+	routes.DELETE("/movers/:id", s.writeLocked(s.deleteMover))
+	routes.POST("/movers/:id/review", s.writeLocked(s.recommendMover))
+	routes.POST("/movers/:id/response-time", s.writeLocked(s.recordResponseTime))
+	routes.DELETE("/movers/:id/reviews/:reviewID", s.adminOnly(s.writeLocked(s.deleteReview)))
+	routes.GET("/movers/:id/ratings/histogram", s.readLocked(s.getRatingHistogram))
+	routes.GET("/movers/:id/alternatives", s.readLocked(s.getAlternatives))
+	routes.GET("/movers/:id/estimate", s.readLocked(s.getMoveEstimate))
+	routes.POST("/movers/:id/review/rank-impact", s.readLocked(s.reviewRankImpact))
+	routes.POST("/movers/:id/restore", s.writeLocked(s.restoreMover))
+	routes.POST("/movers/:id/verify", s.adminOnly(s.writeLocked(s.setVerified(true))))
+	routes.POST("/movers/:id/unverify", s.adminOnly(s.writeLocked(s.setVerified(false))))
+	routes.POST("/movers/:id/feature", s.adminOnly(s.writeLocked(s.setFeatured(true))))
+	routes.POST("/movers/:id/unfeature", s.adminOnly(s.writeLocked(s.setFeatured(false))))
+
+	routes.GET("/admin/reports/implausible", s.adminOnly(s.readLocked(s.getImplausibleReport)))
+	routes.POST("/admin/readonly", s.adminOnly(s.setReadOnly))
 }
 
-// POST request. Add a new mover
-func addMover(context *gin.Context) {
+// deprecatedAlias marks requests to the unversioned API paths and points clients to /v1
+func deprecatedAlias() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		successor := apiVersionPrefix + context.Request.URL.Path
+		slog.Warn("Deprecated unversioned route called", "method", context.Request.Method, "path", context.Request.URL.Path, "successor", successor)
+		context.Header("Deprecation", "true")
+		context.Header("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", successor))
+		context.Next()
+	}
+}
 
-	var newMover mover
-	if err := context.BindJSON(&newMover); err != nil {
-		context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+// newHTTPServer wraps the router with the configured connection timeouts, so slow clients
+// can't hold connections open indefinitely
+func newHTTPServer(config Config, router http.Handler) *http.Server {
+	handler := router
+	if config.RequestTimeout > 0 {
+		// Cancels the request context and answers 503 when a handler runs too long.
+		// The event stream is long-lived by design and bypasses it
+		timeoutHandler := http.TimeoutHandler(router, config.RequestTimeout, `{"error":"Request timed out"}`)
+		handler = http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			if isEventStream(request) {
+				router.ServeHTTP(writer, request)
+				return
+			}
+			timeoutHandler.ServeHTTP(writer, request)
+		})
+	}
+	return &http.Server{
+		Addr:         config.Address(),
+		Handler:      handler,
+		ReadTimeout:  config.ReadTimeout,
+		WriteTimeout: config.WriteTimeout,
+		IdleTimeout:  config.IdleTimeout,
+	}
+}
+
+// methodNotAllowed answers 405 and repeats the supported methods from the Allow header in the body
+func methodNotAllowed(context *gin.Context) {
+	allowed := context.Writer.Header().Get("Allow")
+	context.JSON(http.StatusMethodNotAllowed, gin.H{"error": fmt.Sprintf("Method %s is not allowed, use %s", context.Request.Method, allowed)})
+}
+
+// initializeRouter serves the given store. Tests can give every router a fresh newMemoryStore(defaultMovers())
+func initializeRouter(config Config, store MoverStore) *gin.Engine {
+	router, s := newRouter(config)
+	s.serve(store)
+	return router
+}
+
+// newRouter builds the router before the store is loaded, so the server can listen during a slow load.
+// Data endpoints answer 503 until serve is called on the returned server, /healthz is up right away
+func newRouter(config Config) (*gin.Engine, *server) {
+	registerValidators()
+
+	// Gin's own logger ignores LOG_LEVEL and LOG_FORMAT, requestLogger logs through the service logger
+	router := gin.New()
+	router.Use(requestLogger(slog.Default()), gin.Recovery())
+	router.Use(metricsMiddleware(), gzipMiddleware(), envelopeMiddleware(), bodyLimitMiddleware(config.MaxBodyBytes))
+
+	// A known path with an unsupported method is a 405, not a 404. Gin fills the Allow header
+	router.HandleMethodNotAllowed = true
+	router.NoMethod(methodNotAllowed)
+
+	s := &server{
+		config:        config,
+		webhook:       newWebhookNotifier(config.WebhookURL),
+		idempotency:   newIdempotencyCache(config.IdempotencyTTL),
+		recentReviews: newReviewDedupCache(config.ReviewDedupWindow),
+		events:        newEventHub(config.MaxEventSubscribers),
+	}
+	s.readOnly.Store(config.ReadOnly)
+	// Both groups share one limiter, the aliases count towards the same limit as /v1
+	rateLimit := s.rateLimit()
+	s.registerMoverRoutes(router.Group(apiVersionPrefix, rateLimit, s.requireReady()))
+	// Unversioned aliases, kept until existing clients move to /v1
+	s.registerMoverRoutes(router.Group("", deprecatedAlias(), rateLimit, s.requireReady()))
+
+	// Docs and operational endpoints stay unversioned
+	router.GET("/openapi.json", getOpenAPISpec)
+	router.GET("/docs", getDocs)
+	router.GET("/schema/mover.json", getSchema(moverSchema()))
+	router.GET("/schema/review.json", getSchema(reviewSchema()))
+	router.GET(metricsPath, getMetrics(s))
+	router.GET("/healthz", s.getHealthz)
+	router.GET("/readyz", s.getReadyz)
+	router.GET("/version", getVersion)
+
+	checkOpenAPISpec(router.Routes())
+
+	return router, s
+}
+
+// Main Functions
+// GET request. Sort by rank (Bayesian average rating). If ranks are equal, sort by ID.
+// Filtering, sorting and pagination options are described on listOptions
+func (s *server) getMovers(context *gin.Context) {
+	options, err := parseListOptions(context)
+	if err != nil {
+		var paramErrors queryParamErrors
+		errors.As(err, &paramErrors)
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters", "details": paramErrors})
 		return
 	}
 
-	//checks if mover already exists
-	if checkMoverExists(newMover) {
-		context.JSON(http.StatusNotFound, gin.H{"error": "Mover already exists"})
+	// The default rank order comes from the cached ranked list, apply only filters and pages it.
+	// Otherwise stores that can filter in the database only load the matching movers, apply still sorts them
+	r, ranked := s.ranked()
+	var active []mover
+	querying, isQuerying := s.store.(queryingStore)
+	switch {
+	case options.sortedByRank():
+		active = ranked
+	case isQuerying:
+		active = querying.ListMatching(options)
+	default:
+		active = s.store.List()
+	}
+	page, total := options.apply(active, r, options.sortedByRank())
+	sortedMovers := outputPrecision(context).movers(page)
+
+	if strings.Contains(context.GetHeader("Accept"), "text/csv") {
+		writeMoversCSV(context, sortedMovers)
 		return
 	}
 
-	//Checks if the tel. number is occupied
-	if checkMoverTelNumber(newMover) {
-		context.JSON(http.StatusNotFound, gin.H{"error": "Tel. number is occupied"})
+	// Paginated lists come in an envelope, the whole list as a bare array.
+	// With a cursor the page comes with the cursor of the next one, empty on the last page
+	maskedMovers := options.Fields.movers(sortedMovers)
+	response := maskedMovers
+	switch {
+	case options.CursorPaging:
+		nextCursor := ""
+		if len(page) > 0 && len(page) < total {
+			nextCursor = encodeListCursor(page[len(page)-1], options.sortSpec(), r)
+		}
+		response = gin.H{"movers": maskedMovers, "next_cursor": nextCursor}
+	case options.OffsetPaging:
+		response = offsetPage{
+			Movers: maskedMovers,
+			Total:  total,
+			Limit:  options.Limit,
+			Offset: options.Offset,
+			Links:  options.links(context.Request.URL, total),
+		}
+	}
+
+	// Pollers send the ETag back and get a 304 while the list is unchanged
+	body, err := json.Marshal(response)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"error": "Could not encode movers"})
+		return
+	}
+	etag := etagFor(body)
+	context.Header("ETag", etag)
+	if etagMatches(context.GetHeader("If-None-Match"), etag) {
+		context.Status(http.StatusNotModified)
 		return
 	}
 
-	movers = append(movers, newMover)
-	context.JSON(http.StatusCreated, newMover)
+	context.Data(http.StatusOK, "application/json; charset=utf-8", body)
 }
 
-// DELETE request. Delete mover by ID
-func deleteMover(context *gin.Context) {
-	MoverId, err := extractId(context)
-	if err != nil {
-		context.JSON(http.StatusNotFound, gin.H{"error": "Extracting ID error"})
+// GET request. Export sorted movers as a CSV file. The file is built in memory so its byte
+// offsets are well-defined, and clients can resume an interrupted download with a Range request
+func (s *server) exportMoversCSV(context *gin.Context) {
+	sortedMovers := s.rankedMovers()
+
+	var file bytes.Buffer
+	if err := encodeMoversCSV(&file, outputPrecision(context).movers(sortedMovers)); err != nil {
+		slog.Error("CSV export failed", "error", err)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": "Could not encode movers"})
 		return
 	}
 
-	//!create function DeleteMoverById that will implement delete logic
+	// Sent back in If-Range, the ETag makes sure a resumed download continues the same file.
+	// ServeContent answers Range requests with 206 and Content-Range, and advertises Accept-Ranges
+	context.Header("ETag", etagFor(file.Bytes()))
+	context.Header("Content-Type", "text/csv")
+	context.Header("Content-Disposition", `attachment; filename="movers.csv"`)
+	http.ServeContent(context.Writer, context.Request, "movers.csv", time.Time{}, bytes.NewReader(file.Bytes()))
+}
 
-	moverIndex, err := findMoverIndexById(MoverId)
-	if err != nil {
-		context.JSON(http.StatusNotFound, gin.H{"message": "Mover not found"})
+// writeMoversCSV encodes the rows straight into the response writer, without building the file first.
+// It isn't streamed to the client: REQUEST_TIMEOUT's handler and gzipMiddleware both buffer the
+// whole response before sending it. Ratings are written as given, callers round them to the request's precision
+func writeMoversCSV(context *gin.Context, sortedMovers []mover) {
+	context.Header("Content-Type", "text/csv")
+	context.Header("Content-Disposition", `attachment; filename="movers.csv"`)
+	context.Status(http.StatusOK)
+
+	if err := encodeMoversCSV(context.Writer, sortedMovers); err != nil {
+		slog.Error("CSV export failed", "error", err)
+	}
+}
+
+// encodeMoversCSV writes the header row and one row per mover
+func encodeMoversCSV(output io.Writer, sortedMovers []mover) error {
+	writer := csv.NewWriter(output)
+	_ = writer.Write([]string{"id", "name", "rating", "telephone_number", "jobs_done"})
+	for _, mover := range sortedMovers {
+		_ = writer.Write([]string{
+			strconv.Itoa(mover.ID),
+			mover.Name,
+			strconv.FormatFloat(mover.Rating, 'f', -1, 64),
+			mover.TelephoneNumber,
+			strconv.Itoa(mover.JobsAmount),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// GET request. Rank movers by reviews per job done. If ratios are equal, keep the rank order
+func (s *server) getMostReviewedRelative(context *gin.Context) {
+	sortedMovers := s.rankedMovers()
+
+	sort.SliceStable(sortedMovers, func(i, j int) bool {
+		return reviewRatio(sortedMovers[i]) > reviewRatio(sortedMovers[j])
+	})
+
+	type moverReviewRatio struct {
+		Mover       mover   `json:"mover"`
+		ReviewRatio float64 `json:"review_ratio"`
+	}
+	precision := outputPrecision(context)
+	ranked := make([]moverReviewRatio, 0, len(sortedMovers))
+	for _, mover := range sortedMovers {
+		ranked = append(ranked, moverReviewRatio{Mover: precision.mover(mover), ReviewRatio: math.Round(reviewRatio(mover)*1000) / 1000})
+	}
+
+	context.JSON(http.StatusOK, ranked)
+}
+
+// GET request. Movers within radius_km of lat/lng. Sort by distance, then by rank
+func (s *server) getNearbyMovers(context *gin.Context) {
+	latitude, latErr := strconv.ParseFloat(context.Query("lat"), 64)
+	longitude, lngErr := strconv.ParseFloat(context.Query("lng"), 64)
+	if latErr != nil || lngErr != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "lat and lng query params are required numbers"})
+		return
+	}
+	if err := validateCoordinates(latitude, longitude); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	movers = deleteElement(movers, moverIndex)
+	radiusKm := defaultNearbyRadiusKm
+	if radiusParam := context.Query("radius_km"); radiusParam != "" {
+		parsedRadius, err := strconv.ParseFloat(radiusParam, 64)
+		if err != nil || !isFinite(parsedRadius) || parsedRadius <= 0 {
+			context.JSON(http.StatusBadRequest, gin.H{"error": "radius_km should be a positive number"})
+			return
+		}
+		radiusKm = parsedRadius
+	}
 
-	context.JSON(http.StatusOK, gin.H{"message": "Mover deleted successfully"})
+	type moverDistance struct {
+		Mover      mover   `json:"mover"`
+		DistanceKm float64 `json:"distance_km"`
+	}
+
+	// Sorting by rating first and then stably by distance keeps rating order among equal distances.
+	// Featured movers still come first
+	nearby := []moverDistance{}
+	for _, mover := range s.rankedMovers() {
+		distance := haversineKm(latitude, longitude, mover.Latitude, mover.Longitude)
+		if distance <= radiusKm {
+			nearby = append(nearby, moverDistance{Mover: mover, DistanceKm: distance})
+		}
+	}
+	sort.SliceStable(nearby, func(i, j int) bool {
+		if nearby[i].Mover.Featured != nearby[j].Mover.Featured {
+			return nearby[i].Mover.Featured
+		}
+		return nearby[i].DistanceKm < nearby[j].DistanceKm
+	})
+	precision := outputPrecision(context)
+	for i := range nearby {
+		nearby[i].Mover = precision.mover(nearby[i].Mover)
+		nearby[i].DistanceKm = math.Round(nearby[i].DistanceKm*100) / 100
+	}
+
+	context.JSON(http.StatusOK, nearby)
 }
 
-// POST request. Recommendation from users, updating average mover rate
-func recommendMover(context *gin.Context) {
-	MoverId, err := extractId(context)
+// GET request. Movers available at the given RFC3339 timestamp (now when omitted), sorted by rank.
+// Availability windows are wall-clock times compared in the timestamp's own time zone
+func (s *server) getAvailableMovers(context *gin.Context) {
+	at := time.Now()
+	if atParam := context.Query("at"); atParam != "" {
+		parsedAt, err := time.Parse(time.RFC3339, atParam)
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"error": "at should be an RFC3339 timestamp, e.g. 2024-05-01T10:00:00-07:00"})
+			return
+		}
+		at = parsedAt
+	}
+
+	available := []mover{}
+	for _, mover := range s.rankedMovers() {
+		if isAvailableAt(mover.Availability, at) {
+			available = append(available, mover)
+		}
+	}
+
+	context.JSON(http.StatusOK, outputPrecision(context).movers(available))
+}
+
+// GET request. Top N movers by rank. N defaults to 5 and is capped at 50
+func (s *server) getTopMovers(context *gin.Context) {
+	n := defaultTopMovers
+	if nParam, present := context.GetQuery("n"); present {
+		parsedN, err := strconv.Atoi(nParam)
+		if err != nil || parsedN <= 0 {
+			context.JSON(http.StatusBadRequest, gin.H{"error": "n should be a positive integer"})
+			return
+		}
+		n = min(parsedN, maxTopMovers)
+	}
+
+	sortedMovers := s.rankedMovers()
+	context.JSON(http.StatusOK, outputPrecision(context).movers(sortedMovers[:min(n, len(sortedMovers))]))
+}
+
+// GET request. Movers similar to the given one, e.g. when it's booked out. N defaults to 3 and is capped at 20
+func (s *server) getAlternatives(context *gin.Context) {
+	moverId, err := extractId(context)
 	if err != nil {
-		context.JSON(http.StatusNotFound, gin.H{"error": "Extracting ID error"})
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Mover ID should be a number"})
 		return
 	}
 
-	existingMover, getErr := getMoverById(MoverId)
+	n := defaultAlternatives
+	if nParam, present := context.GetQuery("n"); present {
+		parsedN, err := strconv.Atoi(nParam)
+		if err != nil || parsedN <= 0 {
+			context.JSON(http.StatusBadRequest, gin.H{"error": "n should be a positive integer"})
+			return
+		}
+		n = min(parsedN, maxAlternatives)
+	}
 
+	baseMover, getErr := s.store.Get(moverId)
 	if getErr != nil {
 		context.JSON(http.StatusNotFound, gin.H{"message": "Mover not found"})
 		return
 	}
 
-	var updatedRating mover
+	alternatives := alternativesFor(baseMover, s.store.List(), s.ranker(), n)
+	context.JSON(http.StatusOK, outputPrecision(context).movers(alternatives))
+}
 
-	if err := context.BindJSON(&updatedRating); err != nil {
-		context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
-		return
+// GET request. Infinite-scroll feed of recommended movers in ranking order.
+// after is the next_cursor of the previous page, remaining_high_quality counts the high-quality movers left after this page
+func (s *server) getRecommendationFeed(context *gin.Context) {
+	limit := defaultFeedLimit
+	if limitParam, present := context.GetQuery("limit"); present {
+		parsedLimit, err := strconv.Atoi(limitParam)
+		if err != nil || parsedLimit < 1 || parsedLimit > maxFeedLimit {
+			context.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit should be an integer between 1 and %d", maxFeedLimit)})
+			return
+		}
+		limit = parsedLimit
 	}
 
-	if updatedRating.Rating >= 0.0 && updatedRating.Rating <= 5.0 {
-		totalJobs := existingMover.JobsAmount
+	var cursor *rankCursor
+	if after := context.Query("after"); after != "" {
+		decoded, err := decodeCursor(after)
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		cursor = &decoded
+	}
 
-		existingMover.Rating = (existingMover.Rating*float64(totalJobs) + updatedRating.Rating) / (float64(totalJobs) + 1)
-		existingMover.JobsAmount += 1
-		// Calculate the average rate based on provided rate (if provided)
-	} else {
-		context.JSON(http.StatusExpectationFailed, gin.H{"error": "Provided rate should be in range between 0 and 5"})
+	r := s.ranker()
+	page, rest := pageAfterCursor(s.rankedMovers(), cursor, limit, r)
+
+	remainingHighQuality := 0
+	for _, mover := range rest {
+		if mover.Rating >= highQualityRatingMinimum {
+			remainingHighQuality++
+		}
+	}
+
+	nextCursor := ""
+	if len(page) > 0 && len(rest) > 0 {
+		nextCursor = encodeCursor(page[len(page)-1], r)
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"movers":                 outputPrecision(context).movers(page),
+		"next_cursor":            nextCursor,
+		"remaining_high_quality": remainingHighQuality,
+	})
+}
+
+// GET request. Roster summary for dashboards, computed over active movers
+func (s *server) getMoverStats(context *gin.Context) {
+	stats := computeStats(s.store.List())
+
+	precision := outputPrecision(context)
+	stats.AverageRating = precision.round(stats.AverageRating)
+	if stats.Total > 0 {
+		highest, lowest := precision.mover(*stats.HighestRated), precision.mover(*stats.LowestRated)
+		stats.HighestRated, stats.LowestRated = &highest, &lowest
+	}
+	context.JSON(http.StatusOK, stats)
+}
+
+// GET request. Find the mover a telephone number belongs to, in any of the usual formats
+func (s *server) getMoverByTelNumber(context *gin.Context) {
+	existingMover, getErr := s.store.ByTelNumber(strings.TrimSpace(context.Param("number")))
+	if getErr != nil {
+		context.JSON(http.StatusNotFound, gin.H{"message": "Mover not found"})
 		return
 	}
-	context.JSON(http.StatusOK, existingMover)
+
+	context.JSON(http.StatusOK, outputPrecision(context).mover(existingMover))
 }
 
-func main() {
-	//load .env file
-	err := godotenv.Load(".env")
+// GET request. Get mover by ID
+func (s *server) getMover(context *gin.Context) {
+	MoverId, err := extractId(context)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Mover ID should be a number"})
+		return
+	}
+
+	fieldsParam, hasFields := context.GetQuery("fields")
+	fields, err := parseFieldMask(fieldsParam, hasFields)
 	if err != nil {
-		log.Fatalf("Error loading .env file")
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	existingMover, getErr := s.store.Get(MoverId)
+	if getErr != nil {
+		context.JSON(http.StatusNotFound, gin.H{"message": "Mover not found"})
+		return
 	}
 
-	// getting env variables HOST and PORT
-	serverHost := os.Getenv("HOST")
-	serverPort := os.Getenv("PORT")
+	context.JSON(http.StatusOK, fields.mover(outputPrecision(context).mover(existingMover)))
+}
 
-	router := initializeRouter()
+// POST request. Add a new mover
+func (s *server) addMover(context *gin.Context) {
 
-	routerErr := router.Run(fmt.Sprintf("%s:%s", serverHost, serverPort))
-	if routerErr != nil {
-		log.Fatalf("Server failed to start: %v", err)
+	// Binding tags on mover validate the fields, see validation.go
+	var newMover mover
+	if err := bindBody(context, &newMover); err != nil {
+		respondInvalidBody(context, err)
+		return
+	}
+
+	//checks if mover already exists
+	if s.store.NameTaken(newMover.Name, noExclusion) {
+		context.JSON(http.StatusConflict, gin.H{"error": "Mover already exists"})
+		return
+	}
+
+	if err := normalizeMoverInput(&newMover); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	//Checks if the tel. number is occupied
+	if s.store.TelNumberTaken(newMover.TelephoneNumber, noExclusion) {
+		context.JSON(http.StatusConflict, gin.H{"error": "Tel. number is occupied"})
+		return
+	}
+
+	// IDs are assigned by the store and only admins verify and feature movers, so the client's ID,
+	// verified and featured flags are ignored. Response times and the review count only come from
+	// recorded samples and reviews
+	newMover.Verified, newMover.Featured = false, false
+	newMover.ReviewCount = 0
+	newMover.AvgResponseMinutes, newMover.ResponseSamples = 0, 0
+	newMover.CreatedAt = now()
+	newMover.UpdatedAt = newMover.CreatedAt
+	newMover.setRatingBaseline(nil)
+	added, err := s.store.Add(newMover)
+	if err != nil {
+		respondStoreError(context, err)
+		return
+	}
+	newMover = added[0]
+	s.events.publish(moverAddedEvent, newMover)
+
+	context.Header("Location", fmt.Sprintf("%s/movers/%d", apiVersionPrefix, newMover.ID))
+	context.JSON(http.StatusCreated, outputPrecision(context).mover(newMover))
+}
+
+// normalizeMoverInput brings the services, availability and telephone number of a created or
+// updated mover into their stored form
+func normalizeMoverInput(m *mover) error {
+	services, err := normalizeServices(m.Services)
+	if err != nil {
+		return err
+	}
+	m.Services = services
+
+	availability, err := normalizeAvailability(m.Availability)
+	if err != nil {
+		return err
+	}
+	m.Availability = availability
+
+	// Stored in canonical E.164 form, so "+1 561-555-7689" and "+15615557689" are the same number
+	m.TelephoneNumber = normalizeTelNumber(m.TelephoneNumber)
+	return nil
+}
+
+// PUT request. Replace the fields of a mover, like POST /movers does for a new one
+func (s *server) replaceMover(context *gin.Context) {
+	moverId, err := extractId(context)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Mover ID should be a number"})
+		return
+	}
+	existingMover, getErr := s.store.Get(moverId)
+	if getErr != nil {
+		context.JSON(http.StatusNotFound, gin.H{"message": "Mover not found"})
+		return
+	}
+
+	var updated mover
+	if err := bindBody(context, &updated); err != nil {
+		respondInvalidBody(context, err)
+		return
+	}
+	s.saveMoverUpdate(context, existingMover, updated)
+}
+
+// PATCH request. Change only the fields present in the JSON body, the result is validated as a whole
+func (s *server) patchMover(context *gin.Context) {
+	moverId, err := extractId(context)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Mover ID should be a number"})
+		return
+	}
+	existingMover, getErr := s.store.Get(moverId)
+	if getErr != nil {
+		context.JSON(http.StatusNotFound, gin.H{"message": "Mover not found"})
+		return
+	}
+
+	// Decoding into a slice reuses its backing array, so the stored slices are copied first
+	updated := existingMover
+	updated.Services = slices.Clone(existingMover.Services)
+	updated.Availability = slices.Clone(existingMover.Availability)
+	if err := json.NewDecoder(context.Request.Body).Decode(&updated); err != nil {
+		respondInvalidBody(context, err)
+		return
+	}
+	if err := validateFields(updated); err != nil {
+		respondInvalidBody(context, err)
+		return
+	}
+	s.saveMoverUpdate(context, existingMover, updated)
+}
+
+// saveMoverUpdate checks the updated mover against every other mover and stores it.
+// The ID and creation time can't be changed by the client
+func (s *server) saveMoverUpdate(context *gin.Context, existingMover mover, updated mover) {
+	if s.store.NameTaken(updated.Name, existingMover.ID) {
+		context.JSON(http.StatusConflict, gin.H{"error": "Mover already exists"})
+		return
+	}
+	if err := normalizeMoverInput(&updated); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if s.store.TelNumberTaken(updated.TelephoneNumber, existingMover.ID) {
+		context.JSON(http.StatusConflict, gin.H{"error": "Tel. number is occupied"})
+		return
+	}
+
+	// The review count only changes through reviews. The running totals only still add up if the
+	// update left the rating alone, otherwise the new rating is taken as given, minus the stored
+	// reviews behind it
+	updated.ReviewCount = existingMover.ReviewCount
+	if updated.Rating == existingMover.Rating {
+		updated.RatingSum, updated.RatingWeight = existingMover.RatingSum, existingMover.RatingWeight
+		updated.BaselineRatingSum, updated.BaselineRatingWeight = existingMover.BaselineRatingSum, existingMover.BaselineRatingWeight
+		updated.BaselineReviewCount = existingMover.BaselineReviewCount
+	} else {
+		updated.RatingSum, updated.RatingWeight = 0, 0
+		updated.setRatingBaseline(s.store.Reviews(existingMover.ID))
+	}
+
+	updated.ID = existingMover.ID
+	updated.Verified, updated.Featured = existingMover.Verified, existingMover.Featured
+	updated.AvgResponseMinutes, updated.ResponseSamples = existingMover.AvgResponseMinutes, existingMover.ResponseSamples
+	updated.ResponseMinutesSum = existingMover.ResponseMinutesSum
+	updated.CreatedAt = existingMover.CreatedAt
+	updated.Deleted = false
+	updated.UpdatedAt = now()
+
+	if err := s.store.Update(updated); err != nil {
+		respondStoreError(context, err)
+		return
+	}
+	s.events.publish(moverUpdatedEvent, updated)
+	context.JSON(http.StatusOK, outputPrecision(context).mover(updated))
+}
+
+// bulkResult is the outcome of one entry of a partial bulk import
+type bulkResult struct {
+	Index  int         `json:"index"`
+	Status int         `json:"status"`
+	ID     int         `json:"id,omitempty"`
+	Error  string      `json:"error,omitempty"`
+	Fields fieldErrors `json:"fields,omitempty"`
+}
+
+func failedBulkResult(index int, err error) bulkResult {
+	result := bulkResult{Index: index, Status: http.StatusBadRequest, Error: err.Error()}
+	var fields fieldErrors
+	if errors.As(err, &fields) {
+		result.Error = "Invalid fields"
+		result.Fields = fields
+	}
+	return result
+}
+
+// prepareBulkMovers resets what the server sets itself.
+// IDs are always assigned by the store so the batch can't collide with existing ones
+func prepareBulkMovers(batch []mover) {
+	createdAt := now()
+	for i := range batch {
+		batch[i].Verified, batch[i].Featured = false, false
+		batch[i].AvgResponseMinutes, batch[i].ResponseSamples = 0, 0
+		batch[i].ReviewCount = 0
+		batch[i].CreatedAt = createdAt
+		batch[i].UpdatedAt = createdAt
+		batch[i].setRatingBaseline(nil)
+	}
+}
+
+// POST request. Add many movers at once, all-or-nothing. With ?mode=partial the valid entries
+// are added anyway and 207 reports the outcome of every entry
+func (s *server) addMoversBulk(context *gin.Context) {
+	partial := false
+	switch context.DefaultQuery("mode", "atomic") {
+	case "atomic":
+	case "partial":
+		partial = true
+	default:
+		context.JSON(http.StatusBadRequest, gin.H{"error": "mode should be atomic or partial"})
+		return
+	}
+
+	// Decoded without binding, Gin's slice validation doesn't tell which entry failed.
+	// validateBulkMovers runs the binding tags entry by entry instead
+	var batch []mover
+	if err := json.NewDecoder(context.Request.Body).Decode(&batch); err != nil {
+		respondInvalidBody(context, err)
+		return
+	}
+	// null decodes to an empty batch as well
+	if len(batch) == 0 {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "at least one mover is required"})
+		return
+	}
+
+	if partial {
+		s.addMoversPartially(context, batch)
+		return
+	}
+
+	if index, err := validateBulkMovers(s.store, batch); err != nil {
+		result := failedBulkResult(index, err)
+		response := gin.H{"error": result.Error, "index": index}
+		if result.Fields != nil {
+			response["fields"] = result.Fields
+		}
+		context.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	prepareBulkMovers(batch)
+	added, err := s.store.Add(batch...)
+	if err != nil {
+		respondStoreError(context, err)
+		return
+	}
+	s.events.publish(moverAddedEvent, added...)
+	context.JSON(http.StatusCreated, outputPrecision(context).movers(added))
+}
+
+// addMoversPartially adds the valid entries of a batch. Uniqueness is checked against the accepted
+// entries only, so a rejected entry doesn't block a later one with the same name
+func (s *server) addMoversPartially(context *gin.Context, batch []mover) {
+	results := make([]bulkResult, len(batch))
+	accepted := newBulkNames(len(batch))
+	valid, validIndexes := []mover{}, []int{}
+	for i := range batch {
+		if err := validateBulkMover(s.store, accepted, &batch[i]); err != nil {
+			results[i] = failedBulkResult(i, err)
+			continue
+		}
+		valid = append(valid, batch[i])
+		validIndexes = append(validIndexes, i)
+	}
+
+	prepareBulkMovers(valid)
+	added, err := s.store.Add(valid...)
+	if err != nil {
+		respondStoreError(context, err)
+		return
+	}
+	s.events.publish(moverAddedEvent, added...)
+	for i, addedMover := range added {
+		index := validIndexes[i]
+		results[index] = bulkResult{Index: index, Status: http.StatusCreated, ID: addedMover.ID}
+	}
+	context.JSON(http.StatusMultiStatus, gin.H{"results": results})
+}
+
+// DELETE request. Soft-delete mover by ID, the record is kept so it can be restored.
+// With ?dry_run=true nothing changes and the mover that would be deleted is returned
+func (s *server) deleteMover(context *gin.Context) {
+	MoverId, err := extractId(context)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Mover ID should be a number"})
+		return
+	}
+
+	dryRun := false
+	if dryRunParam := context.Query("dry_run"); dryRunParam != "" {
+		dryRun, err = strconv.ParseBool(dryRunParam)
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"error": "dry_run should be true or false"})
+			return
+		}
+	}
+
+	existingMover, getErr := s.store.Get(MoverId)
+	if getErr != nil {
+		context.JSON(http.StatusNotFound, gin.H{"message": "Mover not found"})
+		return
+	}
+
+	if dryRun {
+		context.JSON(http.StatusOK, outputPrecision(context).mover(existingMover))
+		return
+	}
+
+	if err := s.store.Delete(MoverId, now()); err != nil {
+		respondStoreError(context, err)
+		return
+	}
+	s.publishDeleted(MoverId)
+
+	context.JSON(http.StatusOK, gin.H{"message": "Mover deleted successfully"})
+}
+
+// bulkDeleteRequest lists the IDs to soft-delete in one DELETE /movers
+type bulkDeleteRequest struct {
+	IDs []int `json:"ids"`
+}
+
+// DELETE request. Soft-delete many movers at once. Unknown or already deleted IDs are reported
+// as not found instead of failing, so a partially stale list still makes progress
+func (s *server) deleteMoversBulk(context *gin.Context) {
+	var request bulkDeleteRequest
+	if err := context.ShouldBindJSON(&request); err != nil {
+		respondBindError(context, err, "Invalid JSON")
+		return
+	}
+	if len(request.IDs) == 0 {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "ids should list at least one mover ID"})
+		return
+	}
+
+	// One unit, a storage failure halfway doesn't leave the list half deleted
+	var deleted, notFound []int
+	deletedAt := now()
+	err := s.store.Atomically(func() error {
+		deleted, notFound = []int{}, []int{}
+		for _, moverId := range request.IDs {
+			if slices.Contains(deleted, moverId) || slices.Contains(notFound, moverId) {
+				continue
+			}
+			err := s.store.Delete(moverId, deletedAt)
+			if errors.Is(err, errMoverNotFound) {
+				notFound = append(notFound, moverId)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			deleted = append(deleted, moverId)
+		}
+		return nil
+	})
+	if err != nil {
+		respondStoreError(context, err)
+		return
+	}
+	for _, moverId := range deleted {
+		s.publishDeleted(moverId)
+	}
+
+	context.JSON(http.StatusOK, gin.H{"deleted": deleted, "not_found": notFound})
+}
+
+// POST request. Restore a soft-deleted mover by ID
+func (s *server) restoreMover(context *gin.Context) {
+	MoverId, err := extractId(context)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Mover ID should be a number"})
+		return
+	}
+
+	deletedMover, err := s.store.Find(MoverId)
+	if err != nil {
+		context.JSON(http.StatusNotFound, gin.H{"message": "Mover not found"})
+		return
+	}
+
+	if !deletedMover.Deleted {
+		context.JSON(http.StatusConflict, gin.H{"error": "Mover is not deleted"})
+		return
+	}
+
+	// Only the marker is cleared, rating and jobs stay exactly as they were
+	deletedMover.Deleted = false
+	deletedMover.UpdatedAt = now()
+	if err := s.store.Update(deletedMover); err != nil {
+		respondStoreError(context, err)
+		return
+	}
+	s.events.publish(moverRestoredEvent, deletedMover)
+
+	context.JSON(http.StatusOK, outputPrecision(context).mover(deletedMover))
+}
+
+// POST request. Admin confirmation of a mover's identity and license, or its withdrawal.
+// Setting the flag it already has is not an error, the mover is returned either way
+func (s *server) setVerified(verified bool) gin.HandlerFunc {
+	return s.setAdminFlag(func(m *mover) *bool { return &m.Verified }, verified)
+}
+
+// POST request. Admin placement of a sponsored partner ahead of all other movers, or its end
+func (s *server) setFeatured(featured bool) gin.HandlerFunc {
+	return s.setAdminFlag(func(m *mover) *bool { return &m.Featured }, featured)
+}
+
+// setAdminFlag sets the flag of the mover that only admins control to value
+func (s *server) setAdminFlag(flag func(m *mover) *bool, value bool) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		MoverId, err := extractId(context)
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"error": "Mover ID should be a number"})
+			return
+		}
+
+		existingMover, getErr := s.store.Get(MoverId)
+		if getErr != nil {
+			context.JSON(http.StatusNotFound, gin.H{"message": "Mover not found"})
+			return
+		}
+
+		if *flag(&existingMover) != value {
+			*flag(&existingMover) = value
+			existingMover.UpdatedAt = now()
+			if err := s.store.Update(existingMover); err != nil {
+				respondStoreError(context, err)
+				return
+			}
+			s.events.publish(moverUpdatedEvent, existingMover)
+		}
+		context.JSON(http.StatusOK, outputPrecision(context).mover(existingMover))
+	}
+}
+
+// POST request. Recommendation from users, updating average mover rate
+func (s *server) recommendMover(context *gin.Context) {
+	MoverId, err := extractId(context)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Mover ID should be a number"})
+		return
+	}
+
+	existingMover, getErr := s.store.Get(MoverId)
+
+	if getErr != nil {
+		context.JSON(http.StatusNotFound, gin.H{"message": "Mover not found"})
+		return
+	}
+
+	newReview, bindErr := bindReview(context)
+	if bindErr != nil {
+		respondBindError(context, bindErr, bindErr.Error())
+		return
+	}
+
+	// A retried submission gets the first response back, before the duplicate reviewer check turns it into a 409
+	dedupKey := reviewDedupKey(context, MoverId, newReview.ReviewerID)
+	if previous, found := s.recentReviews.get(dedupKey, *newReview.Rating, *newReview.Weight, time.Now()); found {
+		context.Header("Idempotent-Replayed", "true")
+		context.JSON(http.StatusOK, outputPrecision(context).mover(previous))
+		return
+	}
+
+	if s.config.RejectDuplicateReviewers && s.store.HasReviewFrom(MoverId, newReview.ReviewerID) {
+		context.JSON(http.StatusConflict, gin.H{"error": "This reviewer has already reviewed the mover"})
+		return
+	}
+
+	// The review and the new totals are stored together, or neither is.
+	// The handler holds the write lock, so the totals are read and incremented by one review at a time
+	var recorded review
+	err = s.store.Atomically(func() error {
+		var err error
+		recorded, err = s.store.AddReview(MoverId, newReview.ReviewerID, *newReview.Rating, *newReview.Weight)
+		if err != nil {
+			return err
+		}
+		existingMover.addRating(recorded.Rating, recorded.Weight)
+		existingMover.JobsAmount += 1
+		existingMover.UpdatedAt = now()
+		return s.store.Update(existingMover)
+	})
+	if err != nil {
+		respondStoreError(context, err)
+		return
+	}
+
+	s.webhook.notifyReview(reviewEvent{
+		MoverID:       MoverId,
+		Rating:        recorded.Rating,
+		AverageRating: existingMover.Rating,
+		ReviewedAt:    recorded.CreatedAt,
+	})
+	s.events.publish(moverRatedEvent, existingMover)
+	s.recentReviews.put(dedupKey, recorded.Rating, recorded.Weight, existingMover, time.Now())
+	context.JSON(http.StatusOK, outputPrecision(context).mover(existingMover))
+}
+
+// DELETE request. Admin removal of a review left in error or against policy, the mover's
+// rating and review count are recalculated without it
+func (s *server) deleteReview(context *gin.Context) {
+	MoverId, err := extractId(context)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Mover ID should be a number"})
+		return
+	}
+	reviewId, err := strconv.Atoi(context.Param("reviewID"))
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Review ID should be a number"})
+		return
+	}
+
+	existingMover, getErr := s.store.Get(MoverId)
+	if getErr != nil {
+		context.JSON(http.StatusNotFound, gin.H{"message": "Mover not found"})
+		return
+	}
+
+	err = s.store.Atomically(func() error {
+		deleted, err := s.store.DeleteReview(MoverId, reviewId)
+		if err != nil {
+			return err
+		}
+		existingMover.removeRating(deleted.Rating, deleted.Weight)
+		existingMover.UpdatedAt = now()
+		return s.store.Update(existingMover)
+	})
+	if err != nil {
+		respondStoreError(context, err)
+		return
+	}
+	s.events.publish(moverRatedEvent, existingMover)
+	context.JSON(http.StatusOK, outputPrecision(context).mover(existingMover))
+}
+
+// GET request. Star histogram of the mover's stored reviews
+func (s *server) getRatingHistogram(context *gin.Context) {
+	MoverId, err := extractId(context)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Mover ID should be a number"})
+		return
+	}
+
+	if _, getErr := s.store.Get(MoverId); getErr != nil {
+		context.JSON(http.StatusNotFound, gin.H{"message": "Mover not found"})
+		return
+	}
+
+	histogram, total, average := ratingHistogram(s.store.Reviews(MoverId))
+	context.JSON(http.StatusOK, gin.H{
+		"histogram": histogram,
+		"total":     total,
+		"average":   outputPrecision(context).round(average),
+	})
+}
+
+// POST request. Shows how a hypothetical review would move the mover's rank, nothing is persisted
+func (s *server) reviewRankImpact(context *gin.Context) {
+	MoverId, err := extractId(context)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Mover ID should be a number"})
+		return
+	}
+
+	existingMover, getErr := s.store.Get(MoverId)
+	if getErr != nil {
+		context.JSON(http.StatusNotFound, gin.H{"message": "Mover not found"})
+		return
+	}
+
+	hypotheticalReview, bindErr := bindReview(context)
+	if bindErr != nil {
+		respondBindError(context, bindErr, bindErr.Error())
+		return
+	}
+
+	// Rank the mover in a copy of the list with the hypothetical review applied
+	active := s.store.List()
+	rankBefore := moverRank(s.rankedMovers(), MoverId)
+
+	reviewed := existingMover
+	reviewed.addRating(*hypotheticalReview.Rating, *hypotheticalReview.Weight)
+	reviewed.JobsAmount += 1
+	newRating := reviewed.Rating
+	for i := range active {
+		if active[i].ID == MoverId {
+			active[i] = reviewed
+		}
+	}
+	rankAfter := moverRank(sortMoversByRank(active, s.ranker()), MoverId)
+
+	context.JSON(http.StatusOK, gin.H{
+		"id":            MoverId,
+		"rank_before":   rankBefore,
+		"rank_after":    rankAfter,
+		"rating_before": outputPrecision(context).round(existingMover.Rating),
+		"rating_after":  outputPrecision(context).round(newRating),
+		"rank_change":   rankBefore - rankAfter,
+		"total_movers":  len(active),
+	})
+}
+
+// POST request. Admin safety valve that rebuilds every mover's rating and review count from
+// the stored reviews, e.g. after a manual data edit. Safe to run repeatedly
+func (s *server) recomputeRatings(context *gin.Context) {
+	var checked int
+	var changes []ratingChange
+	err := s.store.Atomically(func() error {
+		var err error
+		checked, changes, err = recomputeRatings(s.store, now())
+		return err
+	})
+	if err != nil {
+		respondStoreError(context, err)
+		return
+	}
+	for _, change := range changes {
+		if recomputed, err := s.store.Get(change.ID); err == nil {
+			s.events.publish(moverRatedEvent, recomputed)
+		}
+	}
+
+	precision := outputPrecision(context)
+	for i := range changes {
+		changes[i].RatingBefore = precision.round(changes[i].RatingBefore)
+		changes[i].RatingAfter = precision.round(changes[i].RatingAfter)
+	}
+	context.JSON(http.StatusOK, gin.H{"checked": checked, "changed": changes})
+}
+
+// GET request. Admin report of movers whose stats look implausible
+func (s *server) getImplausibleReport(context *gin.Context) {
+	type flaggedMover struct {
+		Mover   mover    `json:"mover"`
+		Reasons []string `json:"reasons"`
+	}
+
+	flagged := []flaggedMover{}
+	for _, mover := range s.rankedMovers() {
+		if reasons := implausibilityReasons(mover); len(reasons) > 0 {
+			reported := outputPrecision(context).mover(mover)
+			// JSON can't hold NaN or Inf, the reason names the stored value
+			if !isFinite(reported.Rating) {
+				reported.Rating = 0
+			}
+			flagged = append(flagged, flaggedMover{Mover: reported, Reasons: reasons})
+		}
+	}
+
+	context.JSON(http.StatusOK, gin.H{"flagged": flagged, "total": len(flagged)})
+}
+
+func main() {
+	//load .env file, settings can also come straight from the environment
+	if err := godotenv.Load(".env"); err != nil {
+		log.Printf("No .env file loaded: %v", err)
+	}
+
+	config, err := LoadConfig()
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	slog.SetDefault(newLogger(config, os.Stderr))
+
+	// The server listens right away, data endpoints answer 503 until the store is loaded
+	router, s := newRouter(config)
+	go func() {
+		s.serve(loadStore(config))
+		slog.Info("Store loaded, serving requests", "store", config.Store)
+	}()
+
+	routerErr := newHTTPServer(config, router).ListenAndServe()
+	if routerErr != nil {
+		log.Fatalf("Server failed to start: %v", routerErr)
+	}
+}
+
+// loadStore opens the configured store and checks the loaded movers. Any failure stops the server,
+// it never becomes ready with incomplete data
+func loadStore(config Config) MoverStore {
+	movers := defaultMovers()
+
+	var store MoverStore = newMemoryStore(movers)
+	if config.Store == sqliteBackend {
+		// The seed movers only fill a new, empty database
+		sqlite, err := newSQLiteStore(config.SQLitePath, movers)
+		if err != nil {
+			log.Fatalf("Could not open SQLite database %s: %v", config.SQLitePath, err)
+		}
+		store = sqlite
+	}
+
+	if err := prepareStore(config, store); err != nil {
+		log.Fatalf("Could not prepare the store: %v", err)
+	}
+	return store
+}
+
+// prepareStore cleans up and checks the movers of a freshly opened store before it is served
+func prepareStore(config Config, store MoverStore) error {
+	// Covers whatever the store holds, seed movers as well as rows imported into the database
+	if config.NormalizeOnLoad {
+		corrected, err := normalizeStoredMovers(store)
+		if err != nil {
+			return fmt.Errorf("save normalized movers: %w", err)
+		}
+		slog.Info("Normalization on load done", "corrected", corrected)
+	}
+
+	// A duplicate in the seed list or the database would break the uniqueness the handlers rely on
+	if err := checkMoverConflicts(store.All()); err != nil {
+		return fmt.Errorf("conflicting movers, fix them before starting: %w", err)
 	}
+	return nil
 }
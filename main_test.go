@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testAPIKey = "test-admin-key"
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	m.Run()
+}
+
+// testConfig is the configuration LoadConfig gives without any environment, plus an admin key
+func testConfig() Config {
+	return Config{
+		Host:                     "localhost",
+		Port:                     "8080",
+		BayesianPriorWeight:      defaultBayesianPriorWeight,
+		RatingPrecision:          defaultRatingPrecision,
+		RejectDuplicateReviewers: true,
+		MaxBodyBytes:             defaultMaxBodyBytes,
+		IdempotencyTTL:           defaultIdempotencyTTL,
+		ReviewDedupWindow:        defaultReviewDedupWindow,
+		MaxEventSubscribers:      defaultMaxEventSubscribers,
+		AdminAPIKey:              testAPIKey,
+		Store:                    memoryBackend,
+		StoreWriteRetries:        defaultStoreWriteRetries,
+	}
+}
+
+// testBackend is the store the handler tests run against. They run on memory as plain tests and
+// again on every other store through TestHandlerSuitesOnEveryStore
+var testBackend = memoryBackend
+
+// newTestStore opens a fresh store of testBackend, seeded with the seed movers
+func newTestStore(t *testing.T) MoverStore {
+	t.Helper()
+	return storeBackends[testBackend](t)
+}
+
+// newTestRouter serves a fresh copy of the seed movers from testBackend
+func newTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	router, _ := newTestRouterWithStore(t)
+	return router
+}
+
+// newTestRouterWithStore is newTestRouter for tests that also seed what clients can't set
+func newTestRouterWithStore(t *testing.T) (*gin.Engine, MoverStore) {
+	t.Helper()
+	store := newTestStore(t)
+	return initializeRouter(testConfig(), store), store
+}
+
+// doRequest sends a request to the router. A non-empty body is sent as JSON,
+// headers are given as name, value pairs
+func doRequest(router http.Handler, method, path, body string, headers ...string) *httptest.ResponseRecorder {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	request := httptest.NewRequest(method, path, reader)
+	if body != "" {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		request.Header.Set(headers[i], headers[i+1])
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+// adminRequest sends a request with the admin API key
+func adminRequest(router http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	return doRequest(router, method, path, body, "X-API-Key", testAPIKey)
+}
+
+// decode unmarshals a response body, failing the test when it isn't the expected JSON
+func decode[T any](t *testing.T, recorder *httptest.ResponseRecorder) T {
+	t.Helper()
+	var value T
+	if err := json.Unmarshal(recorder.Body.Bytes(), &value); err != nil {
+		t.Fatalf("decoding response %q: %v", recorder.Body.String(), err)
+	}
+	return value
+}
+
+// expectStatus fails the test when the response doesn't have the wanted status
+func expectStatus(t *testing.T, recorder *httptest.ResponseRecorder, want int) {
+	t.Helper()
+	if recorder.Code != want {
+		t.Fatalf("status %d, want %d, body %s", recorder.Code, want, recorder.Body.String())
+	}
+}
+
+func TestSlowBodyDoesNotBlockReaders(t *testing.T) {
+	router := newTestRouter(t)
+
+	// A client that sent the headers of a write but is still uploading the body
+	body, upload := io.Pipe()
+	defer upload.Close()
+	request := httptest.NewRequest(http.MethodPost, "/v1/movers", body)
+	request.Header.Set("Content-Type", "application/json")
+	writeDone := make(chan struct{})
+	go func() {
+		defer close(writeDone)
+		router.ServeHTTP(httptest.NewRecorder(), request)
+	}()
+	_, _ = upload.Write([]byte(`{"name": "Slow`))
+
+	readDone := make(chan int)
+	go func() {
+		readDone <- doRequest(router, http.MethodGet, "/v1/movers", "").Code
+	}()
+	select {
+	case status := <-readDone:
+		if status != http.StatusOK {
+			t.Fatalf("GET /v1/movers status %d during a slow upload", status)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("GET /v1/movers was blocked by a write still reading its body")
+	}
+
+	upload.Close()
+	<-writeDone
+}
+
+func TestImplausibleReportNeedsAdminKey(t *testing.T) {
+	router := newTestRouter(t)
+	expectStatus(t, doRequest(router, http.MethodGet, "/v1/admin/reports/implausible", ""), http.StatusUnauthorized)
+	expectStatus(t, doRequest(router, http.MethodGet, "/v1/admin/reports/implausible", "", "X-API-Key", "wrong"), http.StatusUnauthorized)
+	expectStatus(t, adminRequest(router, http.MethodGet, "/v1/admin/reports/implausible", ""), http.StatusOK)
+
+	config := testConfig()
+	config.AdminAPIKey = ""
+	disabled := initializeRouter(config, newTestStore(t))
+	expectStatus(t, doRequest(disabled, http.MethodGet, "/v1/admin/reports/implausible", ""), http.StatusForbidden)
+}
+
+func TestImplausibleMoversAreReported(t *testing.T) {
+	router := initializeRouter(testConfig(), newMemoryStore([]mover{
+		{ID: 1, Name: "Plausible Movers", TelephoneNumber: "+15551800001", Rating: 4.3, JobsAmount: 1234, ReviewCount: 321},
+		{ID: 2, Name: "Round Movers", TelephoneNumber: "+15551800002", Rating: 4.3, JobsAmount: 1500, ReviewCount: 321},
+		{ID: 3, Name: "Perfect Movers", TelephoneNumber: "+15551800003", Rating: 5, JobsAmount: 2345, ReviewCount: 321},
+		{ID: 4, Name: "Overreviewed Movers", TelephoneNumber: "+15551800004", Rating: 4.3, JobsAmount: 12, ReviewCount: 13},
+		{ID: 5, Name: "Overrated Movers", TelephoneNumber: "+15551800005", Rating: 7.5, JobsAmount: 12, ReviewCount: 3},
+		{ID: 6, Name: "Underrated Movers", TelephoneNumber: "+15551800006", Rating: -1, JobsAmount: 12, ReviewCount: 3},
+		{ID: 7, Name: "Unrated Movers", TelephoneNumber: "+15551800007", Rating: math.NaN(), JobsAmount: 12, ReviewCount: 3},
+		{ID: 8, Name: "Infinite Movers", TelephoneNumber: "+15551800008", Rating: math.Inf(1), JobsAmount: 200, ReviewCount: 300},
+	}))
+	want := map[int][]string{
+		2: {"jobs_done is an exact multiple of 100"},
+		3: {"rating is exactly 5.0 across 2345 jobs"},
+		4: {"review_count is higher than jobs_done"},
+		5: {"rating 7.5 is outside 0 to 5"},
+		6: {"rating -1 is outside 0 to 5"},
+		7: {"rating is NaN"},
+		8: {"rating is +Inf", "jobs_done is an exact multiple of 100", "review_count is higher than jobs_done"},
+	}
+
+	recorder := adminRequest(router, http.MethodGet, "/v1/admin/reports/implausible", "")
+	expectStatus(t, recorder, http.StatusOK)
+	report := decode[struct {
+		Flagged []struct {
+			Mover   mover    `json:"mover"`
+			Reasons []string `json:"reasons"`
+		} `json:"flagged"`
+		Total int `json:"total"`
+	}](t, recorder)
+
+	if report.Total != len(want) || len(report.Flagged) != len(want) {
+		t.Fatalf("%d movers flagged, want %d: %s", report.Total, len(want), recorder.Body.String())
+	}
+	for _, flagged := range report.Flagged {
+		if reasons, expected := want[flagged.Mover.ID]; !expected || !slices.Equal(flagged.Reasons, reasons) {
+			t.Errorf("mover %d flagged for %q, want %q", flagged.Mover.ID, flagged.Reasons, reasons)
+		}
+	}
+}
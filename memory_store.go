@@ -0,0 +1,217 @@
+package main
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// memoryStore keeps the movers and their reviews in memory
+type memoryStore struct {
+	sync.RWMutex
+	movers       []mover
+	reviews      []review // reviews recorded through the API, seed aggregates have no individual reviews
+	lastReviewId int
+	// Index of normalized telephone number -> mover ID, kept in sync on every mutation.
+	// Soft-deleted movers stay indexed so a restore can't collide with a newer mover
+	telNumberIndex map[string]int
+	inUnit         bool // set while Atomically runs, nested calls join it
+}
+
+// newMemoryStore builds a store holding a copy of the given movers. They have no stored reviews
+// yet, so their whole aggregate becomes the rating baseline
+func newMemoryStore(movers []mover) *memoryStore {
+	storedMovers := make([]mover, len(movers))
+	copy(storedMovers, movers)
+	for i := range storedMovers {
+		if storedMovers[i].BaselineReviewCount == 0 {
+			storedMovers[i].setRatingBaseline(nil)
+		}
+	}
+	return &memoryStore{
+		movers:         storedMovers,
+		reviews:        []review{},
+		telNumberIndex: buildTelNumberIndex(storedMovers),
+	}
+}
+
+func (store *memoryStore) All() []mover {
+	all := make([]mover, len(store.movers))
+	copy(all, store.movers)
+	return all
+}
+
+func (store *memoryStore) List() []mover {
+	active := make([]mover, 0, len(store.movers))
+	for _, mover := range store.movers {
+		if !mover.Deleted {
+			active = append(active, mover)
+		}
+	}
+	return active
+}
+
+func (store *memoryStore) Get(id int) (mover, error) {
+	found, err := store.Find(id)
+	if err != nil || found.Deleted {
+		return mover{}, errMoverNotFound
+	}
+	return found, nil
+}
+
+func (store *memoryStore) Find(id int) (mover, error) {
+	index := store.indexOf(id)
+	if index < 0 {
+		return mover{}, errMoverNotFound
+	}
+	return store.movers[index], nil
+}
+
+func (store *memoryStore) indexOf(id int) int {
+	for index, mover := range store.movers {
+		if mover.ID == id {
+			return index
+		}
+	}
+	return -1
+}
+
+func (store *memoryStore) ByTelNumber(telNumber string) (mover, error) {
+	moverId, found := store.telNumberIndex[normalizeTelNumber(telNumber)]
+	if !found {
+		return mover{}, errMoverNotFound
+	}
+	// The index keeps soft-deleted movers, which are not returned
+	return store.Get(moverId)
+}
+
+func (store *memoryStore) NameTaken(name string, excludeID int) bool {
+	for _, existingMover := range store.movers {
+		if existingMover.ID != excludeID && sameName(existingMover.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (store *memoryStore) TelNumberTaken(telNumber string, excludeID int) bool {
+	ownerId, occupied := store.telNumberIndex[normalizeTelNumber(telNumber)]
+	return occupied && ownerId != excludeID
+}
+
+func (store *memoryStore) Add(movers ...mover) ([]mover, error) {
+	nextId := store.nextMoverId()
+	added := make([]mover, len(movers))
+	for i, newMover := range movers {
+		newMover.ID = nextId + i
+		store.movers = append(store.movers, newMover)
+		store.telNumberIndex[normalizeTelNumber(newMover.TelephoneNumber)] = newMover.ID
+		added[i] = newMover
+	}
+	return added, nil
+}
+
+func (store *memoryStore) Update(m mover) error {
+	index := store.indexOf(m.ID)
+	if index < 0 {
+		return errMoverNotFound
+	}
+	delete(store.telNumberIndex, normalizeTelNumber(store.movers[index].TelephoneNumber))
+	store.telNumberIndex[normalizeTelNumber(m.TelephoneNumber)] = m.ID
+	store.movers[index] = m
+	return nil
+}
+
+func (store *memoryStore) Delete(id int, at time.Time) error {
+	index := store.indexOf(id)
+	if index < 0 || store.movers[index].Deleted {
+		return errMoverNotFound
+	}
+	store.movers[index].Deleted = true
+	store.movers[index].UpdatedAt = at
+	return nil
+}
+
+func (store *memoryStore) nextMoverId() int {
+	maxId := 0
+	for _, mover := range store.movers {
+		if mover.ID > maxId {
+			maxId = mover.ID
+		}
+	}
+	return maxId + 1
+}
+
+func (store *memoryStore) AddReview(moverId int, reviewerId string, rating, weight float64) (review, error) {
+	newReview := review{
+		ID:         store.lastReviewId + 1,
+		MoverID:    moverId,
+		ReviewerID: reviewerId,
+		Rating:     rating,
+		Weight:     weight,
+		CreatedAt:  now(),
+	}
+	store.reviews = append(store.reviews, newReview)
+	store.lastReviewId = newReview.ID
+	return newReview, nil
+}
+
+func (store *memoryStore) DeleteReview(moverId, reviewId int) (review, error) {
+	for i, review := range store.reviews {
+		if review.ID == reviewId && review.MoverID == moverId {
+			store.reviews = append(store.reviews[:i], store.reviews[i+1:]...)
+			return review, nil
+		}
+	}
+	return review{}, errReviewNotFound
+}
+
+// HasReviewFrom compares reviewer IDs case-insensitively, anonymous reviews never match
+func (store *memoryStore) HasReviewFrom(moverId int, reviewerId string) bool {
+	if reviewerId == "" {
+		return false
+	}
+	for _, review := range store.reviews {
+		if review.MoverID == moverId && strings.EqualFold(review.ReviewerID, reviewerId) {
+			return true
+		}
+	}
+	return false
+}
+
+func (store *memoryStore) Reviews(moverId int) []review {
+	moverReviews := []review{}
+	for _, review := range store.reviews {
+		if review.MoverID == moverId {
+			moverReviews = append(moverReviews, review)
+		}
+	}
+	return moverReviews
+}
+
+// Atomically snapshots the store and puts the snapshot back when fn fails or panics.
+// Stored movers are only ever replaced as a whole, so copying the slices is enough
+func (store *memoryStore) Atomically(fn func() error) error {
+	if store.inUnit {
+		return fn()
+	}
+	movers, reviews := slices.Clone(store.movers), slices.Clone(store.reviews)
+	lastReviewId, telNumberIndex := store.lastReviewId, maps.Clone(store.telNumberIndex)
+
+	store.inUnit = true
+	committed := false
+	defer func() {
+		store.inUnit = false
+		if !committed {
+			store.movers, store.reviews = movers, reviews
+			store.lastReviewId, store.telNumberIndex = lastReviewId, telNumberIndex
+		}
+	}()
+	if err := fn(); err != nil {
+		return err
+	}
+	committed = true
+	return nil
+}
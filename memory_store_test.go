@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"testing"
+)
+
+// scanTelNumber is the linear scan the telephone index replaced
+func scanTelNumber(store *memoryStore, telNumber string) (mover, bool) {
+	for _, candidate := range store.movers {
+		if normalizeTelNumber(candidate.TelephoneNumber) == normalizeTelNumber(telNumber) {
+			return candidate, true
+		}
+	}
+	return mover{}, false
+}
+
+func TestTelNumberIndexStaysConsistent(t *testing.T) {
+	store := newMemoryStore(defaultMovers())
+	router := initializeRouter(testConfig(), store)
+	oldNumber := store.movers[1].TelephoneNumber
+
+	id := addTestMover(t, router, store, "Indexed Movers", "+15550600001", 4, 10)
+	expectStatus(t, doRequest(router, http.MethodPost, "/v1/movers/bulk", `[{"name": "Bulk Indexed", "telephone_number": "+15550600002"}]`), http.StatusCreated)
+	expectStatus(t, doRequest(router, http.MethodPatch, "/v1/movers/2", `{"telephone_number": "+15550600003"}`), http.StatusOK)
+	expectStatus(t, doRequest(router, http.MethodDelete, fmt.Sprintf("/v1/movers/%d", id), ""), http.StatusOK)
+	expectStatus(t, doRequest(router, http.MethodDelete, "/v1/movers/3", ""), http.StatusOK)
+	expectStatus(t, doRequest(router, http.MethodPost, "/v1/movers/3/restore", ""), http.StatusOK)
+
+	if rebuilt := buildTelNumberIndex(store.movers); !maps.Equal(store.telNumberIndex, rebuilt) {
+		t.Fatalf("index drifted from the movers:\nkept    %v\nrebuilt %v", store.telNumberIndex, rebuilt)
+	}
+	for _, number := range []string{oldNumber, "+15550600001", "+15550600002", "+15550600003", "(555) 060-0003", store.movers[2].TelephoneNumber} {
+		scanned, occupied := scanTelNumber(store, number)
+		if store.TelNumberTaken(number, noExclusion) != occupied {
+			t.Errorf("TelNumberTaken(%q) = %t, the scan says %t", number, !occupied, occupied)
+		}
+		indexed, err := store.ByTelNumber(number)
+		if want := occupied && !scanned.Deleted; (err == nil) != want || (want && indexed.ID != scanned.ID) {
+			t.Errorf("ByTelNumber(%q) = %d, %v, the scan found %d (deleted %t)", number, indexed.ID, err, scanned.ID, scanned.Deleted)
+		}
+	}
+	if store.TelNumberTaken(oldNumber, noExclusion) {
+		t.Errorf("mover 2's old number %s is still taken", oldNumber)
+	}
+}
+
+func BenchmarkTelNumberLookup(b *testing.B) {
+	store := newMemoryStore(manyMovers(10000))
+	last := store.movers[len(store.movers)-1].TelephoneNumber
+	b.Run("index", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := store.ByTelNumber(last); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("scan", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, found := scanTelNumber(store, last); !found {
+				b.Fatal("not found")
+			}
+		}
+	})
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"strconv"
+	"time"
+)
+
+const metricsPath = "/metrics"
+
+var httpRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "http_requests_total",
+	Help: "Number of HTTP requests by method, route template and status.",
+}, []string{"method", "route", "status"})
+
+var httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "http_request_duration_seconds",
+	Help:    "HTTP request latency by method and route template.",
+	Buckets: prometheus.DefBuckets,
+}, []string{"method", "route"})
+
+var moversCurrent = promauto.NewGauge(prometheus.GaugeOpts{
+	Name: "movers_current",
+	Help: "Current number of movers, soft-deleted ones excluded.",
+})
+
+// metricsMiddleware records request count and latency. Routes are labeled by their Gin template
+// (e.g. /movers/:id) rather than the raw path, so IDs don't blow up label cardinality
+func metricsMiddleware() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		if context.FullPath() == metricsPath {
+			context.Next()
+			return
+		}
+
+		start := time.Now()
+		context.Next()
+
+		route := context.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		method := context.Request.Method
+
+		httpRequestsTotal.WithLabelValues(method, route, strconv.Itoa(context.Writer.Status())).Inc()
+		httpRequestDuration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+	}
+}
+
+// GET request. Prometheus scrape endpoint. movers_current is read from the store on every scrape,
+// it stays 0 while the store is loading
+func getMetrics(s *server) gin.HandlerFunc {
+	handler := promhttp.Handler()
+	return func(context *gin.Context) {
+		if s.isReady() {
+			s.store.RLock()
+			moversCurrent.Set(float64(len(s.store.List())))
+			s.store.RUnlock()
+		}
+		handler.ServeHTTP(context.Writer, context.Request)
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// messyMovers look like a hand-edited import: padded names, formatted numbers, ratings out of range
+func messyMovers() []mover {
+	return []mover{
+		{ID: 1, Name: "  Padded Movers ", TelephoneNumber: "+1 (561) 555-7689", Rating: 7.5, ReviewCount: 10},
+		{ID: 2, Name: "Negative Movers", TelephoneNumber: "+1.415.553.8692", Rating: -1},
+		{ID: 3, Name: "Clean Movers", TelephoneNumber: "+18025559482", Rating: 4.5},
+	}
+}
+
+func TestNormalizeOnLoad(t *testing.T) {
+	config := testConfig()
+	config.NormalizeOnLoad = true
+
+	stores := map[string]func(t *testing.T) (MoverStore, func() MoverStore){
+		memoryBackend: func(t *testing.T) (MoverStore, func() MoverStore) {
+			store := newMemoryStore(messyMovers())
+			return store, func() MoverStore { return store }
+		},
+		// Checked again after reopening the database, the corrections have to be saved
+		sqliteBackend: func(t *testing.T) (MoverStore, func() MoverStore) {
+			path := filepath.Join(t.TempDir(), "movers.db")
+			open := func() MoverStore {
+				store, err := newSQLiteStore(path, messyMovers())
+				if err != nil {
+					t.Fatal(err)
+				}
+				t.Cleanup(func() { _ = store.db.Close() })
+				return store
+			}
+			return open(), open
+		},
+	}
+
+	want := map[int]struct {
+		name, telNumber string
+		rating          float64
+	}{
+		1: {"Padded Movers", "+15615557689", 5},
+		2: {"Negative Movers", "+14155538692", 0},
+		3: {"Clean Movers", "+18025559482", 4.5},
+	}
+	for name, openStores := range stores {
+		t.Run(name, func(t *testing.T) {
+			store, reopen := openStores(t)
+			if err := prepareStore(config, store); err != nil {
+				t.Fatal(err)
+			}
+			if sqlite, ok := store.(*sqliteStore); ok {
+				_ = sqlite.db.Close()
+			}
+
+			for _, m := range reopen().All() {
+				expected := want[m.ID]
+				if m.Name != expected.name || m.TelephoneNumber != expected.telNumber || m.Rating != expected.rating {
+					t.Errorf("mover %d is %q %s %v, want %q %s %v", m.ID, m.Name, m.TelephoneNumber, m.Rating,
+						expected.name, expected.telNumber, expected.rating)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// OpenAPI document describing every route registered in initializeRouter
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+// Minimal Swagger UI page, assets are loaded from the swagger-ui-dist CDN
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+	<meta charset="utf-8">
+	<title>Movers Recommendation API</title>
+	<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+	<div id="swagger-ui"></div>
+	<script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+	<script>
+		window.ui = SwaggerUIBundle({url: "/openapi.json", dom_id: "#swagger-ui"});
+	</script>
+</body>
+</html>`
+
+var ginPathParam = regexp.MustCompile(`:([^/]+)`)
+
+// GET request. Serve the OpenAPI document
+func getOpenAPISpec(context *gin.Context) {
+	context.Data(http.StatusOK, "application/json", openAPISpec)
+}
+
+// GET request. Serve Swagger UI for the OpenAPI document
+func getDocs(context *gin.Context) {
+	context.Data(http.StatusOK, "text/html; charset=utf-8", []byte(swaggerUIPage))
+}
+
+// checkOpenAPISpec logs every registered route that the OpenAPI document doesn't describe,
+// so a new route without documentation shows up at startup
+func checkOpenAPISpec(routes gin.RoutesInfo) {
+	var spec struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(openAPISpec, &spec); err != nil {
+		slog.Error("OpenAPI spec is not valid JSON", "error", err)
+		return
+	}
+
+	for _, route := range routes {
+		specPath := ginPathParam.ReplaceAllString(route.Path, "{$1}")
+		method := strings.ToLower(route.Method)
+		// Deprecated unversioned aliases are documented through their /v1 route
+		if _, ok := spec.Paths[apiVersionPrefix+specPath][method]; ok {
+			continue
+		}
+		if _, ok := spec.Paths[specPath][method]; !ok {
+			slog.Warn("OpenAPI spec is missing a route", "method", route.Method, "path", specPath)
+		}
+	}
+}
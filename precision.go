@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"math"
+	"net/http"
+	"strconv"
+)
+
+const (
+	defaultRatingPrecision ratingPrecision = 1
+	fullPrecision          ratingPrecision = -1 // ratings are output unrounded
+	maxRatingPrecision                     = 10
+	ratingPrecisionKey                     = "ratingPrecision"
+)
+
+// ratingPrecision is the number of decimal places ratings are rounded to in responses.
+// Stored ratings always keep full precision, rounding only happens when shaping a response
+type ratingPrecision int
+
+// parseRatingPrecision accepts a number of decimals from 0 to 10, or "full"
+func parseRatingPrecision(value string) (ratingPrecision, error) {
+	if value == "full" {
+		return fullPrecision, nil
+	}
+	decimals, err := strconv.Atoi(value)
+	if err != nil || decimals < 0 || decimals > maxRatingPrecision {
+		return 0, fmt.Errorf("precision should be an integer between 0 and %d or full, got %q", maxRatingPrecision, value)
+	}
+	return ratingPrecision(decimals), nil
+}
+
+func (p ratingPrecision) round(rating float64) float64 {
+	if p == fullPrecision {
+		return rating
+	}
+	scale := math.Pow10(int(p))
+	return math.Round(rating*scale) / scale
+}
+
+// mover returns a copy of the mover with its rating rounded for output
+func (p ratingPrecision) mover(m mover) mover {
+	m.Rating = p.round(m.Rating)
+	return m
+}
+
+func (p ratingPrecision) movers(movers []mover) []mover {
+	rounded := make([]mover, len(movers))
+	for i, mover := range movers {
+		rounded[i] = p.mover(mover)
+	}
+	return rounded
+}
+
+// precisionMiddleware resolves the ?precision= query param, falling back to the configured default
+func precisionMiddleware(defaultPrecision ratingPrecision) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		precision := defaultPrecision
+		if precisionParam, present := context.GetQuery("precision"); present {
+			parsed, err := parseRatingPrecision(precisionParam)
+			if err != nil {
+				context.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			precision = parsed
+		}
+		context.Set(ratingPrecisionKey, precision)
+		context.Next()
+	}
+}
+
+// outputPrecision is the rating precision of the current request
+func outputPrecision(context *gin.Context) ratingPrecision {
+	if precision, ok := context.Get(ratingPrecisionKey); ok {
+		return precision.(ratingPrecision)
+	}
+	return defaultRatingPrecision
+}
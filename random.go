@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+)
+
+// pickRandomMovers returns up to count distinct movers in random order. math/rand/v2 seeds its
+// global source from the OS, so picks differ between process starts
+func pickRandomMovers(movers []mover, count int) []mover {
+	picked := make([]mover, 0, min(count, len(movers)))
+	for _, index := range rand.Perm(len(movers))[:cap(picked)] {
+		picked = append(picked, movers[index])
+	}
+	return picked
+}
+
+// GET request. A random mover matching the GET /movers filters, for discovery.
+// With ?count=N up to N distinct movers are returned as a list
+func (s *server) getRandomMovers(context *gin.Context) {
+	options, err := parseListOptions(context)
+	var paramErrors queryParamErrors
+	errors.As(err, &paramErrors)
+
+	count := 1
+	countParam, hasCount := context.GetQuery("count")
+	if hasCount {
+		count, err = strconv.Atoi(countParam)
+		if err != nil || count < 1 || count > maxListLimit {
+			paramErrors = append(paramErrors, fmt.Sprintf("count should be an integer between 1 and %d", maxListLimit))
+		}
+	}
+	if len(paramErrors) > 0 {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters", "details": paramErrors})
+		return
+	}
+
+	var candidates []mover
+	if querying, ok := s.store.(queryingStore); ok {
+		candidates = querying.ListMatching(options)
+	} else {
+		candidates = s.store.List()
+	}
+	matching := []mover{}
+	for _, candidate := range candidates {
+		if options.matches(candidate) {
+			matching = append(matching, candidate)
+		}
+	}
+	if len(matching) == 0 {
+		context.JSON(http.StatusNotFound, gin.H{"message": "No mover matches the filters"})
+		return
+	}
+
+	// Every request is a new pick, caches must not replay one
+	context.Header("Cache-Control", "no-store")
+	picked := outputPrecision(context).movers(pickRandomMovers(matching, count))
+	if !hasCount {
+		context.JSON(http.StatusOK, options.Fields.mover(picked[0]))
+		return
+	}
+	context.JSON(http.StatusOK, options.Fields.movers(picked))
+}
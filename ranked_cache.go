@@ -0,0 +1,50 @@
+package main
+
+import (
+	"slices"
+	"sync"
+)
+
+// rankedCache keeps the active movers sorted by rank, with the ranker they were sorted with, so
+// read-heavy traffic doesn't copy and sort the whole list on every request. Reads fill it lazily,
+// writeLocked invalidates it after every mutation while it still holds the store's exclusive lock,
+// so no read can see an order older than the last write
+type rankedCache struct {
+	lock   sync.Mutex
+	valid  bool
+	ranker ranker
+	movers []mover
+}
+
+func (cache *rankedCache) invalidate() {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	cache.valid = false
+	cache.movers = nil
+}
+
+// get returns the cached ranker and ranked movers, computing them first if a write invalidated them.
+// Concurrent readers under the shared store lock compute them at most once
+func (cache *rankedCache) get(compute func() (ranker, []mover)) (ranker, []mover) {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	if !cache.valid {
+		cache.ranker, cache.movers = compute()
+		cache.valid = true
+	}
+	return cache.ranker, cache.movers
+}
+
+// ranked returns the cached ranker and ranked movers of the store
+func (s *server) ranked() (ranker, []mover) {
+	return s.rankCache.get(func() (ranker, []mover) {
+		r := s.computeRanker()
+		return r, sortMoversByRank(s.store.List(), r)
+	})
+}
+
+// rankedMovers returns the active movers, best ranked first. A copy, callers may sort or change it
+func (s *server) rankedMovers() []mover {
+	_, movers := s.ranked()
+	return slices.Clone(movers)
+}
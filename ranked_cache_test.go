@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// manyMovers returns n movers with unique names and numbers and spread out ratings
+func manyMovers(n int) []mover {
+	movers := make([]mover, n)
+	for i := range movers {
+		movers[i] = mover{
+			ID:              i + 1,
+			Name:            fmt.Sprintf("Mover %d", i+1),
+			TelephoneNumber: fmt.Sprintf("+1555%07d", i+1),
+			Rating:          float64(i%50) / 10,
+			ReviewCount:     i % 1000,
+			JobsAmount:      i % 1000,
+			CreatedAt:       seededAt,
+			UpdatedAt:       seededAt,
+		}
+	}
+	return movers
+}
+
+// BenchmarkTopMovers serves a read-heavy mix, one review per 100 reads of GET /movers/top.
+// uncached drops the ranked cache before every read, which is what each read cost before it
+func BenchmarkTopMovers(b *testing.B) {
+	for _, cached := range []bool{true, false} {
+		name := "cached"
+		if !cached {
+			name = "uncached"
+		}
+		b.Run(name, func(b *testing.B) {
+			router, s := newRouter(testConfig())
+			s.serve(newMemoryStore(manyMovers(2000)))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if i%100 == 99 {
+					doRequest(router, http.MethodPost, "/v1/movers/1/review", `{"rating": 5}`)
+				}
+				if !cached {
+					s.rankCache.invalidate()
+				}
+				if recorder := doRequest(router, http.MethodGet, "/v1/movers/top?n=10", ""); recorder.Code != http.StatusOK {
+					b.Fatalf("status %d", recorder.Code)
+				}
+			}
+		})
+	}
+}
+
+// Every kind of write shows in the very next read of the cached ranking
+func TestRankedCacheReflectsWritesImmediately(t *testing.T) {
+	router, store := newTestRouterWithStore(t)
+	top := func() []int { return rankedIds(t, router, "/v1/movers/top?n=50") }
+	if ids := top(); ids[0] != 5 {
+		t.Fatalf("seed ranking starts with %v, want mover 5 first", ids[:3])
+	}
+
+	// Added: a mover with a huge perfect record goes straight to the top
+	newId := addTestMover(t, router, store, "Newcomer Movers", "+15550500001", 5, 100000)
+	if ids := top(); ids[0] != newId {
+		t.Errorf("after adding, top starts with %v, want %d", ids[:3], newId)
+	}
+
+	// Rated: reviews are folded into the ranking right away
+	rated := addTestMover(t, router, store, "Rated Movers", "+15550500002", 2, 1)
+	before := indexOf(top(), rated)
+	for i := 0; i < 5; i++ {
+		expectStatus(t, doRequest(router, http.MethodPost, fmt.Sprintf("/v1/movers/%d/review", rated), `{"rating": 5, "weight": 3}`), http.StatusOK)
+	}
+	if after := indexOf(top(), rated); after >= before {
+		t.Errorf("mover %d stayed at position %d after good reviews, was %d", rated, after, before)
+	}
+
+	// Featured and unfeatured
+	expectStatus(t, adminRequest(router, http.MethodPost, "/v1/movers/11/feature", ""), http.StatusOK)
+	if ids := top(); ids[0] != 11 {
+		t.Errorf("after featuring, top starts with %v, want 11", ids[:3])
+	}
+	expectStatus(t, adminRequest(router, http.MethodPost, "/v1/movers/11/unfeature", ""), http.StatusOK)
+	if ids := top(); ids[0] == 11 {
+		t.Error("mover 11 stayed first after unfeaturing")
+	}
+
+	// Deleted and restored
+	expectStatus(t, doRequest(router, http.MethodDelete, fmt.Sprintf("/v1/movers/%d", newId), ""), http.StatusOK)
+	if indexOf(top(), newId) >= 0 {
+		t.Error("deleted mover is still ranked")
+	}
+	expectStatus(t, doRequest(router, http.MethodPost, fmt.Sprintf("/v1/movers/%d/restore", newId), ""), http.StatusOK)
+	if ids := top(); ids[0] != newId {
+		t.Errorf("after restoring, top starts with %v, want %d", ids[:3], newId)
+	}
+
+	// Patched rating
+	expectStatus(t, doRequest(router, http.MethodPatch, fmt.Sprintf("/v1/movers/%d", newId), `{"rating": 0}`), http.StatusOK)
+	if ids := top(); ids[len(ids)-1] != newId {
+		t.Errorf("after patching the rating to 0, mover %d isn't last: %v", newId, ids)
+	}
+}
+
+// indexOf is the position of the ID in the list, -1 if it's missing
+func indexOf(ids []int, id int) int {
+	for i, candidate := range ids {
+		if candidate == id {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+)
+
+// Default number of "virtual" reviews at the global mean that every mover starts with.
+// Higher values pull movers with few reviews harder towards the mean
+const defaultBayesianPriorWeight = 50.0
+
+// ranker scores movers with a Bayesian average, so a single 5.0 review can't outrank
+// thousands of 4.8 ones. The raw Rating is still what clients see
+type ranker struct {
+	globalMean  float64
+	priorWeight float64
+}
+
+// newRanker uses the mean rating of all active movers as the prior, so filtering a list
+// doesn't change the relative order of the movers left in it
+func newRanker(active []mover, priorWeight float64) ranker {
+	mean := 0.0
+	for _, mover := range active {
+		mean += mover.Rating
+	}
+	if len(active) > 0 {
+		mean /= float64(len(active))
+	}
+	return ranker{globalMean: mean, priorWeight: priorWeight}
+}
+
+func (r ranker) score(m mover) float64 {
+	reviews := float64(m.ReviewCount)
+	if reviews+r.priorWeight == 0 {
+		return m.Rating
+	}
+	return (reviews*m.Rating + r.priorWeight*r.globalMean) / (reviews + r.priorWeight)
+}
+
+// rankOrder compares two movers by rank: featured movers first, then by score descending
+func rankOrder(featuredA bool, scoreA float64, featuredB bool, scoreB float64) int {
+	if featuredA != featuredB {
+		if featuredA {
+			return -1
+		}
+		return 1
+	}
+	return cmp.Compare(scoreB, scoreA)
+}
+
+// compareIdentity is the final tiebreak of every mover order: ID ascending, then name, then creation time.
+// IDs are unique once loaded, see checkMoverConflicts, the other keys only keep the order deterministic
+// should two movers ever share one. Movers equal in all three keep their store order, the sorts are stable
+func compareIdentity(a, b mover) int {
+	if result := cmp.Compare(a.ID, b.ID); result != 0 {
+		return result
+	}
+	if result := strings.Compare(a.Name, b.Name); result != 0 {
+		return result
+	}
+	return a.CreatedAt.Compare(b.CreatedAt)
+}
+
+// compare orders featured movers first, then by score descending. If scores are equal, by compareIdentity
+func (r ranker) compare(a, b mover) int {
+	if result := rankOrder(a.Featured, r.score(a), b.Featured, r.score(b)); result != 0 {
+		return result
+	}
+	return compareIdentity(a, b)
+}
+
+// sortMoversByRank returns a sorted copy of the movers, best ranked first
+func sortMoversByRank(movers []mover, r ranker) []mover {
+	moversCopy := slices.Clone(movers)
+	slices.SortStableFunc(moversCopy, r.compare)
+	return moversCopy
+}
@@ -0,0 +1,311 @@
+package main
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rankedIds returns the IDs of a mover list response in order
+func rankedIds(t *testing.T, router http.Handler, path string) []int {
+	t.Helper()
+	recorder := doRequest(router, http.MethodGet, path, "")
+	expectStatus(t, recorder, http.StatusOK)
+	ids := []int{}
+	for _, m := range decode[[]mover](t, recorder) {
+		ids = append(ids, m.ID)
+	}
+	return ids
+}
+
+// addTestMover creates a mover through the API and returns its ID. Clients can't set the review
+// count, so it is seeded through the store, like the seed movers come with theirs. The POST already
+// invalidated the ranked cache and nothing read it since, so no write goes around it
+func addTestMover(t *testing.T, router http.Handler, store MoverStore, name, telNumber string, rating float64, reviewCount int) int {
+	t.Helper()
+	body := fmt.Sprintf(`{"name": %q, "telephone_number": %q, "rating": %v, "jobs_done": %d}`, name, telNumber, rating, reviewCount)
+	recorder := doRequest(router, http.MethodPost, "/v1/movers", body)
+	expectStatus(t, recorder, http.StatusCreated)
+	added := decode[mover](t, recorder)
+	if reviewCount == 0 {
+		return added.ID
+	}
+
+	store.Lock()
+	defer store.Unlock()
+	seeded, err := store.Get(added.ID)
+	if err != nil {
+		t.Fatalf("getting the added mover: %v", err)
+	}
+	seeded.ReviewCount = reviewCount
+	seeded.setRatingBaseline(nil)
+	if err := store.Update(seeded); err != nil {
+		t.Fatalf("seeding the review count: %v", err)
+	}
+	return added.ID
+}
+
+func TestFeaturedMoverOutranksHigherRated(t *testing.T) {
+	router, store := newTestRouterWithStore(t)
+	featured := addTestMover(t, router, store, "Sponsored Movers", "+15550100001", 4.0, 100)
+	better := addTestMover(t, router, store, "Better Movers", "+15550100002", 4.9, 5000)
+	expectStatus(t, adminRequest(router, http.MethodPost, fmt.Sprintf("/v1/movers/%d/feature", featured), ""), http.StatusOK)
+
+	for _, path := range []string{"/v1/movers", "/v1/movers/top?n=3"} {
+		if ids := rankedIds(t, router, path); len(ids) < 2 || ids[0] != featured || ids[1] != better {
+			t.Errorf("%s: want featured 4.0 mover %d, then 4.9 mover %d first, got %v", path, featured, better, ids)
+		}
+	}
+	// Both new movers sit at 0,0, next to each other, so nearby still puts the featured one first
+	recorder := doRequest(router, http.MethodGet, "/v1/movers/nearby?lat=0&lng=0&radius_km=10", "")
+	expectStatus(t, recorder, http.StatusOK)
+	nearby := decode[[]struct{ Mover mover }](t, recorder)
+	if len(nearby) != 2 || nearby[0].Mover.ID != featured {
+		t.Errorf("nearby: featured mover %d isn't first: %+v", featured, nearby)
+	}
+
+	expectStatus(t, adminRequest(router, http.MethodPost, fmt.Sprintf("/v1/movers/%d/unfeature", featured), ""), http.StatusOK)
+	if ids := rankedIds(t, router, "/v1/movers"); ids[0] != better {
+		t.Errorf("after unfeature the 4.9 mover should lead, got %v", ids)
+	}
+}
+
+func TestClientsCannotFeatureMovers(t *testing.T) {
+	router := newTestRouter(t)
+
+	recorder := doRequest(router, http.MethodPost, "/v1/movers", `{"name": "Self Promoted", "telephone_number": "+15550100003", "featured": true}`)
+	expectStatus(t, recorder, http.StatusCreated)
+	created := decode[mover](t, recorder)
+	if created.Featured {
+		t.Error("POST /movers kept featured from the body")
+	}
+
+	for _, request := range []struct{ method, body string }{
+		{http.MethodPatch, `{"featured": true}`},
+		{http.MethodPut, `{"name": "Self Promoted", "telephone_number": "+15550100003", "featured": true}`},
+	} {
+		recorder := doRequest(router, request.method, fmt.Sprintf("/v1/movers/%d", created.ID), request.body)
+		expectStatus(t, recorder, http.StatusOK)
+		if decode[mover](t, recorder).Featured {
+			t.Errorf("%s /movers/:id kept featured from the body", request.method)
+		}
+	}
+
+	recorder = doRequest(router, http.MethodPost, "/v1/movers/bulk", `[{"name": "Bulk Promoted", "telephone_number": "+15550100004", "featured": true}]`)
+	expectStatus(t, recorder, http.StatusCreated)
+	if decode[[]mover](t, recorder)[0].Featured {
+		t.Error("POST /movers/bulk kept featured from the body")
+	}
+
+	expectStatus(t, doRequest(router, http.MethodPost, fmt.Sprintf("/v1/movers/%d/feature", created.ID), ""), http.StatusUnauthorized)
+}
+
+// A perfect rating over a made-up review count would outweigh the prior and put the mover first
+func TestClientsCannotSetReviewCount(t *testing.T) {
+	router := newTestRouter(t)
+	top := func() int { return rankedIds(t, router, "/v1/movers/top?n=1")[0] }
+	leader := top()
+
+	recorder := doRequest(router, http.MethodPost, "/v1/movers", `{"name": "Self Reviewed", "telephone_number": "+15550100005", "rating": 5, "review_count": 100000000}`)
+	expectStatus(t, recorder, http.StatusCreated)
+	created := decode[mover](t, recorder)
+	if created.ReviewCount != 0 || top() != leader {
+		t.Errorf("POST /movers kept review_count %d from the body, top mover is %d", created.ReviewCount, top())
+	}
+
+	form := postForm(router, "/v1/movers", url.Values{"name": {"Form Reviewed"}, "telephone_number": {"+15550100006"}, "rating": {"5"}, "review_count": {"100000000"}})
+	expectStatus(t, form, http.StatusCreated)
+	if reviewCount := decode[mover](t, form).ReviewCount; reviewCount != 0 || top() != leader {
+		t.Errorf("form POST /movers kept review_count %d from the body, top mover is %d", reviewCount, top())
+	}
+
+	for _, request := range []struct{ method, body string }{
+		{http.MethodPatch, `{"review_count": 100000000}`},
+		{http.MethodPut, `{"name": "Self Reviewed", "telephone_number": "+15550100005", "rating": 5, "review_count": 100000000}`},
+	} {
+		recorder := doRequest(router, request.method, fmt.Sprintf("/v1/movers/%d", created.ID), request.body)
+		expectStatus(t, recorder, http.StatusOK)
+		if reviewCount := decode[mover](t, recorder).ReviewCount; reviewCount != 0 || top() != leader {
+			t.Errorf("%s /movers/:id kept review_count %d from the body, top mover is %d", request.method, reviewCount, top())
+		}
+	}
+
+	// A seed mover keeps the reviews it has
+	seed := defaultMovers()[0]
+	recorder = doRequest(router, http.MethodPatch, fmt.Sprintf("/v1/movers/%d", seed.ID), `{"review_count": 1}`)
+	expectStatus(t, recorder, http.StatusOK)
+	if reviewCount := decode[mover](t, recorder).ReviewCount; reviewCount != seed.ReviewCount {
+		t.Errorf("PATCH changed the seed review_count from %d to %d", seed.ReviewCount, reviewCount)
+	}
+
+	for _, bulk := range []struct {
+		path, body string
+		want       int
+	}{
+		{"/v1/movers/bulk", `[{"name": "Bulk Reviewed", "telephone_number": "+15550100007", "rating": 5, "review_count": 100000000}]`, http.StatusCreated},
+		{"/v1/movers/bulk?mode=partial", `[{"name": "Partially Reviewed", "telephone_number": "+15550100008", "rating": 5, "review_count": 100000000}]`, http.StatusMultiStatus},
+	} {
+		expectStatus(t, doRequest(router, http.MethodPost, bulk.path, bulk.body), bulk.want)
+		if top() != leader {
+			t.Errorf("POST %s kept review_count from the body, top mover is %d", bulk.path, top())
+		}
+	}
+}
+
+func TestEstablishedMoverOutranksSingleReview(t *testing.T) {
+	build := func(priorWeight float64) (http.Handler, int, int) {
+		config := testConfig()
+		config.BayesianPriorWeight = priorWeight
+		store := newTestStore(t)
+		router := initializeRouter(config, store)
+		lucky := addTestMover(t, router, store, "Lucky Movers", "+15550200001", 5.0, 1)
+		established := addTestMover(t, router, store, "Established Movers", "+15550200002", 4.8, 5000)
+		return router, lucky, established
+	}
+
+	router, lucky, established := build(defaultBayesianPriorWeight)
+	for _, path := range []string{"/v1/movers", "/v1/movers/top?n=50"} {
+		ids := rankedIds(t, router, path)
+		if indexOf(ids, established) > indexOf(ids, lucky) {
+			t.Errorf("%s: the 5.0/1 review mover %d ranks above the 4.8/5000 reviews mover %d: %v", path, lucky, established, ids)
+		}
+	}
+	// The ranking doesn't change the rating clients see
+	if got := decode[mover](t, doRequest(router, http.MethodGet, fmt.Sprintf("/v1/movers/%d", lucky), "")); got.Rating != 5.0 {
+		t.Errorf("raw rating %v, want 5", got.Rating)
+	}
+
+	// A prior weight of 0 falls back to the raw rating
+	router, lucky, established = build(0)
+	if ids := rankedIds(t, router, "/v1/movers"); indexOf(ids, lucky) > indexOf(ids, established) {
+		t.Errorf("with BAYESIAN_PRIOR_WEIGHT=0 the 5.0 mover %d should rank above the 4.8 mover %d: %v", lucky, established, ids)
+	}
+}
+
+func TestHighReviewMovesMoverUp(t *testing.T) {
+	router, store := newTestRouterWithStore(t)
+	id := addTestMover(t, router, store, "Hopeful Movers", "+15550300001", 3.0, 2)
+	path := fmt.Sprintf("/v1/movers/%d/review/rank-impact", id)
+
+	recorder := doRequest(router, http.MethodPost, path, `{"rating": 5, "weight": 3}`)
+	expectStatus(t, recorder, http.StatusOK)
+	impact := decode[struct {
+		RankBefore   int     `json:"rank_before"`
+		RankAfter    int     `json:"rank_after"`
+		RankChange   int     `json:"rank_change"`
+		RatingBefore float64 `json:"rating_before"`
+		RatingAfter  float64 `json:"rating_after"`
+	}](t, recorder)
+	if impact.RankAfter >= impact.RankBefore || impact.RankChange != impact.RankBefore-impact.RankAfter {
+		t.Errorf("a 5 star review doesn't move the mover up: %+v", impact)
+	}
+	if impact.RatingAfter <= impact.RatingBefore {
+		t.Errorf("a 5 star review doesn't raise the rating: %+v", impact)
+	}
+	if ids := rankedIds(t, router, "/v1/movers"); indexOf(ids, id)+1 != impact.RankBefore {
+		t.Errorf("rank_before %d, the mover is listed at %d", impact.RankBefore, indexOf(ids, id)+1)
+	}
+
+	// Nothing is persisted, the same question gets the same answer
+	after := decode[mover](t, doRequest(router, http.MethodGet, fmt.Sprintf("/v1/movers/%d", id), ""))
+	if after.Rating != 3.0 || after.ReviewCount != 2 {
+		t.Errorf("rank-impact changed the mover: %+v", after)
+	}
+	if again := doRequest(router, http.MethodPost, path, `{"rating": 5, "weight": 3}`); again.Body.String() != recorder.Body.String() {
+		t.Errorf("second rank-impact answered %s, first %s", again.Body.String(), recorder.Body.String())
+	}
+}
+
+func TestMostReviewedRelativeOrdersByRatio(t *testing.T) {
+	router := initializeRouter(testConfig(), newMemoryStore([]mover{
+		{ID: 1, Name: "Half Reviewed", TelephoneNumber: "+15550400001", Rating: 4, ReviewCount: 10, JobsAmount: 20},
+		{ID: 2, Name: "Mostly Reviewed", TelephoneNumber: "+15550400002", Rating: 4, ReviewCount: 9, JobsAmount: 10},
+		{ID: 3, Name: "No Jobs Yet", TelephoneNumber: "+15550400003", Rating: 4, ReviewCount: 5, JobsAmount: 0},
+		{ID: 4, Name: "Rarely Reviewed", TelephoneNumber: "+15550400004", Rating: 4, ReviewCount: 1, JobsAmount: 100},
+	}))
+
+	recorder := doRequest(router, http.MethodGet, "/v1/movers/most-reviewed-relative", "")
+	expectStatus(t, recorder, http.StatusOK)
+	ranked := decode[[]struct {
+		Mover       mover   `json:"mover"`
+		ReviewRatio float64 `json:"review_ratio"`
+	}](t, recorder)
+
+	// Zero jobs count as a 0 ratio instead of dividing by zero
+	want := []struct {
+		id    int
+		ratio float64
+	}{{2, 0.9}, {1, 0.5}, {4, 0.01}, {3, 0}}
+	if len(ranked) != len(want) {
+		t.Fatalf("%d movers, want %d: %+v", len(ranked), len(want), ranked)
+	}
+	for i, expected := range want {
+		if ranked[i].Mover.ID != expected.id || ranked[i].ReviewRatio != expected.ratio {
+			t.Errorf("position %d: mover %d ratio %v, want mover %d ratio %v", i, ranked[i].Mover.ID, ranked[i].ReviewRatio, expected.id, expected.ratio)
+		}
+	}
+}
+
+func TestDuplicateIdsSortStably(t *testing.T) {
+	later := seededAt.Add(time.Hour)
+	// A bad data file: three movers share ID 7 and a rating, two of them also the name
+	movers := []mover{
+		{ID: 7, Name: "Beta Movers", TelephoneNumber: "+15551400001", Rating: 4.5, ReviewCount: 100, CreatedAt: later},
+		{ID: 8, Name: "Other Movers", TelephoneNumber: "+15551400002", Rating: 4.5, ReviewCount: 100, CreatedAt: seededAt},
+		{ID: 7, Name: "Alpha Movers", TelephoneNumber: "+15551400003", Rating: 4.5, ReviewCount: 100, CreatedAt: later},
+		{ID: 7, Name: "Beta Movers", TelephoneNumber: "+15551400004", Rating: 4.5, ReviewCount: 100, CreatedAt: seededAt},
+		{ID: 6, Name: "Zeta Movers", TelephoneNumber: "+15551400005", Rating: 4.5, ReviewCount: 100, CreatedAt: seededAt},
+	}
+	want := []string{"+15551400005", "+15551400003", "+15551400004", "+15551400001", "+15551400002"}
+	r := newRanker(movers, defaultBayesianPriorWeight)
+	options := listOptions{Sort: []sortKey{{Field: "rating", Desc: true}}}
+
+	shuffler := rand.New(rand.NewPCG(1, 2))
+	for i := 0; i < 50; i++ {
+		shuffled := slices.Clone(movers)
+		shuffler.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+
+		byListOrder := slices.Clone(shuffled)
+		slices.SortStableFunc(byListOrder, func(a, b mover) int { return options.compare(a, b, r.score(a), r.score(b)) })
+		for name, sorted := range map[string][]mover{"rank": sortMoversByRank(shuffled, r), "sort=-rating": byListOrder} {
+			got := []string{}
+			for _, m := range sorted {
+				got = append(got, m.TelephoneNumber)
+			}
+			if !slices.Equal(got, want) {
+				t.Fatalf("%s order of shuffle %d is %v, want %v", name, i, got, want)
+			}
+		}
+	}
+
+	// Loading such a file is refused
+	if err := checkMoverConflicts(movers); err == nil || !strings.Contains(err.Error(), "ID 7 is used by") {
+		t.Errorf("checkMoverConflicts = %v, want the duplicate ID reported", err)
+	}
+}
+
+func TestNearbyRejectsNonFiniteValues(t *testing.T) {
+	router := newTestRouter(t)
+	expectStatus(t, doRequest(router, http.MethodGet, "/v1/movers/nearby?lat=37.77&lng=-122.42&radius_km=10", ""), http.StatusOK)
+
+	cases := map[string]string{
+		"lat=NaN&lng=-122.42":                  "latitude should be in range between -90 and 90",
+		"lat=37.77&lng=NaN":                    "longitude should be in range between -180 and 180",
+		"lat=-Inf&lng=-122.42":                 "latitude should be in range between -90 and 90",
+		"lat=37.77&lng=-122.42&radius_km=NaN":  "radius_km should be a positive number",
+		"lat=37.77&lng=-122.42&radius_km=Inf":  "radius_km should be a positive number",
+		"lat=37.77&lng=-122.42&radius_km=+Inf": "radius_km should be a positive number",
+	}
+	for query, want := range cases {
+		recorder := doRequest(router, http.MethodGet, "/v1/movers/nearby?"+strings.ReplaceAll(query, "+", "%2B"), "")
+		expectStatus(t, recorder, http.StatusBadRequest)
+		if message := decode[map[string]string](t, recorder)["error"]; message != want {
+			t.Errorf("%s: error %q, want %q", query, message, want)
+		}
+	}
+}
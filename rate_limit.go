@@ -0,0 +1,88 @@
+package main
+
+import (
+	"github.com/gin-gonic/gin"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// rateBucket is a token bucket holding up to a minute's worth of requests, refilled continuously
+type rateBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter keeps one bucket per client. perMinute requests a minute are allowed on average,
+// and up to perMinute in a burst
+type rateLimiter struct {
+	lock      sync.Mutex
+	perMinute int
+	buckets   map[string]*rateBucket
+	lastSweep time.Time
+}
+
+func newRateLimiter(perMinute int) *rateLimiter {
+	return &rateLimiter{perMinute: perMinute, buckets: map[string]*rateBucket{}}
+}
+
+// allow takes a token from the client's bucket. Without one left it returns how long until the next one
+func (limiter *rateLimiter) allow(client string, at time.Time) (bool, time.Duration) {
+	limiter.lock.Lock()
+	defer limiter.lock.Unlock()
+
+	// Buckets idle for a minute are full again, dropping them changes nothing but keeps the map small
+	if at.Sub(limiter.lastSweep) > time.Minute {
+		for storedClient, bucket := range limiter.buckets {
+			if at.Sub(bucket.last) > time.Minute {
+				delete(limiter.buckets, storedClient)
+			}
+		}
+		limiter.lastSweep = at
+	}
+
+	capacity := float64(limiter.perMinute)
+	perSecond := capacity / 60
+	bucket, found := limiter.buckets[client]
+	if !found {
+		bucket = &rateBucket{tokens: capacity, last: at}
+		limiter.buckets[client] = bucket
+	}
+	bucket.tokens = math.Min(capacity, bucket.tokens+at.Sub(bucket.last).Seconds()*perSecond)
+	bucket.last = at
+
+	if bucket.tokens < 1 {
+		return false, time.Duration((1 - bucket.tokens) / perSecond * float64(time.Second))
+	}
+	bucket.tokens--
+	return true, 0
+}
+
+// rateLimit answers 429 with Retry-After to clients over their limit. Anonymous clients are limited
+// by IP with RATE_LIMIT. Requests with the admin API key are limited by the key instead, with
+// ADMIN_RATE_LIMIT, so internal tools aren't throttled by anonymous traffic from the same address.
+// A wrong key counts as anonymous. A limit of 0 disables it
+func (s *server) rateLimit() gin.HandlerFunc {
+	anonymous := newRateLimiter(s.config.RateLimit)
+	admin := newRateLimiter(s.config.AdminRateLimit)
+	return func(context *gin.Context) {
+		limiter, client := anonymous, "ip:"+context.ClientIP()
+		if s.validAPIKey(context.GetHeader("X-API-Key")) {
+			limiter, client = admin, "key:admin"
+		}
+		if limiter.perMinute == 0 {
+			context.Next()
+			return
+		}
+
+		allowed, retryAfter := limiter.allow(client, time.Now())
+		if !allowed {
+			context.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			context.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests, slow down"})
+			return
+		}
+		context.Next()
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestAuthenticatedClientsAreNotThrottled(t *testing.T) {
+	for _, adminLimit := range []int{0, 1000} {
+		t.Run(fmt.Sprintf("admin limit %d", adminLimit), func(t *testing.T) {
+			config := testConfig()
+			config.RateLimit = 5
+			config.AdminRateLimit = adminLimit
+			router := initializeRouter(config, newTestStore(t))
+
+			// The anonymous client runs out after its burst
+			for i := 0; i < config.RateLimit; i++ {
+				expectStatus(t, doRequest(router, http.MethodGet, "/v1/movers", ""), http.StatusOK)
+			}
+			throttled := doRequest(router, http.MethodGet, "/v1/movers", "")
+			expectStatus(t, throttled, http.StatusTooManyRequests)
+			if throttled.Header().Get("Retry-After") == "" {
+				t.Error("429 without Retry-After")
+			}
+			expectStatus(t, doRequest(router, http.MethodGet, "/v1/movers", "", "X-API-Key", "wrong"), http.StatusTooManyRequests)
+
+			// The admin tool calling from the same address keeps going
+			for i := 0; i < 50; i++ {
+				expectStatus(t, adminRequest(router, http.MethodGet, "/v1/movers", ""), http.StatusOK)
+			}
+		})
+	}
+}
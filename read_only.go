@@ -0,0 +1,30 @@
+package main
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// readOnlyResponse is the body of every write while the server is in read-only mode
+var readOnlyResponse = gin.H{"error": gin.H{"code": "read_only", "message": "The server is in read-only mode for maintenance, writes are rejected"}}
+
+// readOnlyRequest is the body of POST /admin/readonly. A pointer so an omitted flag is a 400, not false
+type readOnlyRequest struct {
+	Enabled *bool `json:"enabled" form:"enabled" binding:"required"`
+}
+
+func (s *server) isReadOnly() bool {
+	return s.readOnly.Load()
+}
+
+// POST request. Admin switch of read-only mode at runtime, e.g. around a data migration.
+// READ_ONLY sets the mode the server starts in
+func (s *server) setReadOnly(context *gin.Context) {
+	var request readOnlyRequest
+	if err := bindBody(context, &request); err != nil {
+		respondInvalidBody(context, err)
+		return
+	}
+	s.readOnly.Store(*request.Enabled)
+	context.JSON(http.StatusOK, gin.H{"read_only": *request.Enabled})
+}
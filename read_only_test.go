@@ -0,0 +1,80 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// readOnlyWrites are mutating requests that all succeed once read-only mode is off
+var readOnlyWrites = []struct {
+	method, path, body string
+	admin              bool
+	want               int
+}{
+	{http.MethodPost, "/v1/movers", newMoverBody, false, http.StatusCreated},
+	{http.MethodPost, "/v1/movers/bulk", `[{"name": "Bulk Read Only", "telephone_number": "+15551600001"}]`, false, http.StatusCreated},
+	{http.MethodPatch, "/v1/movers/2", `{"hourly_rate": 99}`, false, http.StatusOK},
+	{http.MethodPost, "/v1/movers/2/review", `{"rating": 5}`, false, http.StatusOK},
+	{http.MethodDelete, "/v1/movers/3", "", false, http.StatusOK},
+	{http.MethodPost, "/v1/movers/4/feature", "", true, http.StatusOK},
+}
+
+func TestReadOnlyMode(t *testing.T) {
+	config := testConfig()
+	config.ReadOnly = true
+	router := initializeRouter(config, newTestStore(t))
+	healthz := func() bool {
+		recorder := doRequest(router, http.MethodGet, "/healthz", "")
+		expectStatus(t, recorder, http.StatusOK)
+		return decode[struct {
+			ReadOnly bool `json:"read_only"`
+		}](t, recorder).ReadOnly
+	}
+	send := func(method, path, body string, admin bool) *httptest.ResponseRecorder {
+		if admin {
+			return adminRequest(router, method, path, body)
+		}
+		return doRequest(router, method, path, body)
+	}
+
+	if !healthz() {
+		t.Error("/healthz doesn't report READ_ONLY")
+	}
+	for _, write := range readOnlyWrites {
+		recorder := send(write.method, write.path, write.body, write.admin)
+		expectStatus(t, recorder, http.StatusServiceUnavailable)
+		body := decode[struct {
+			Error struct{ Code string }
+		}](t, recorder)
+		if body.Error.Code != "read_only" {
+			t.Errorf("%s %s: error code %q, want read_only", write.method, write.path, body.Error.Code)
+		}
+	}
+	for _, path := range []string{"/v1/movers", "/v1/movers/3", "/v1/movers/top", "/v1/movers.csv", "/v1/movers/stats"} {
+		expectStatus(t, doRequest(router, http.MethodGet, path, ""), http.StatusOK)
+	}
+	// A what-if POST doesn't write, so it's still answered
+	expectStatus(t, doRequest(router, http.MethodPost, "/v1/movers/2/review/rank-impact", `{"rating": 5}`), http.StatusOK)
+	if got := decode[mover](t, doRequest(router, http.MethodGet, "/v1/movers/2", "")); got.HourlyRate != defaultMovers()[1].HourlyRate {
+		t.Error("a rejected write changed mover 2")
+	}
+
+	// Only admins flip the mode
+	expectStatus(t, doRequest(router, http.MethodPost, "/v1/admin/readonly", `{"enabled": false}`), http.StatusUnauthorized)
+	expectStatus(t, adminRequest(router, http.MethodPost, "/v1/admin/readonly", `{}`), http.StatusBadRequest)
+	expectStatus(t, adminRequest(router, http.MethodPost, "/v1/admin/readonly", `{"enabled": false}`), http.StatusOK)
+	if healthz() {
+		t.Error("/healthz still reports read-only mode")
+	}
+	for _, write := range readOnlyWrites {
+		if status := send(write.method, write.path, write.body, write.admin).Code; status != write.want {
+			t.Errorf("%s %s after turning read-only off: status %d, want %d", write.method, write.path, status, write.want)
+		}
+	}
+
+	expectStatus(t, adminRequest(router, http.MethodPost, "/v1/admin/readonly", `{"enabled": true}`), http.StatusOK)
+	if status := send(http.MethodPost, "/v1/movers/5/review", `{"rating": 1}`, false).Code; status != http.StatusServiceUnavailable {
+		t.Errorf("review after turning read-only back on: status %d, want 503", status)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+// Seconds clients are asked to wait in Retry-After while the store is still loading
+const startingRetryAfter = 1
+
+// startingResponse is the body of data endpoints until the store is loaded
+var startingResponse = gin.H{"error": gin.H{"code": "starting", "message": "The server is still loading its data, retry shortly"}}
+
+// serve hands the loaded store to the handlers and flips the server to ready.
+// Handlers only read s.store once isReady is true, the atomic flag orders the two
+func (s *server) serve(store MoverStore) {
+	s.store = store
+	s.ready.Store(true)
+}
+
+func (s *server) isReady() bool {
+	return s.ready.Load()
+}
+
+// requireReady answers 503 with Retry-After instead of serving empty or partial data while the
+// store is loading, e.g. a large SQLite database at boot
+func (s *server) requireReady() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		if !s.isReady() {
+			context.Header("Retry-After", strconv.Itoa(startingRetryAfter))
+			context.AbortWithStatusJSON(http.StatusServiceUnavailable, startingResponse)
+			return
+		}
+		context.Next()
+	}
+}
+
+// GET request. Liveness probe, up as soon as the server listens, also while the store loads.
+// read_only tells whether writes are currently rejected
+func (s *server) getHealthz(context *gin.Context) {
+	context.JSON(http.StatusOK, gin.H{"status": "ok", "read_only": s.isReadOnly()})
+}
+
+// GET request. Readiness probe, 503 like the data endpoints until the store is loaded
+func (s *server) getReadyz(context *gin.Context) {
+	if !s.isReady() {
+		context.Header("Retry-After", strconv.Itoa(startingRetryAfter))
+		context.JSON(http.StatusServiceUnavailable, startingResponse)
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{"status": "ready"})
+}
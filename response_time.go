@@ -0,0 +1,59 @@
+package main
+
+import (
+	"github.com/gin-gonic/gin"
+	"math"
+	"net/http"
+)
+
+// responseTimeRequest is one measured response to a customer inquiry. Samples over a week are
+// data entry mistakes rather than slow movers
+type responseTimeRequest struct {
+	Minutes *float64 `json:"minutes" form:"minutes" binding:"required,gt=0,lte=10080"`
+}
+
+// addResponseSample adds a sample to the running total and derives the average from it, the same
+// way addRating does for ratings, so samples arriving together all count exactly
+func (m *mover) addResponseSample(minutes float64) {
+	m.ResponseMinutesSum += minutes
+	m.ResponseSamples += 1
+	m.AvgResponseMinutes = m.ResponseMinutesSum / float64(m.ResponseSamples)
+}
+
+// responseSortKey is the average response time for sorting. Movers without samples sort as slowest
+func responseSortKey(m mover) float64 {
+	if m.ResponseSamples == 0 {
+		return math.Inf(1)
+	}
+	return m.AvgResponseMinutes
+}
+
+// POST request. Record how long the mover took to respond to an inquiry
+func (s *server) recordResponseTime(context *gin.Context) {
+	MoverId, err := extractId(context)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Mover ID should be a number"})
+		return
+	}
+
+	existingMover, getErr := s.store.Get(MoverId)
+	if getErr != nil {
+		context.JSON(http.StatusNotFound, gin.H{"message": "Mover not found"})
+		return
+	}
+
+	var sample responseTimeRequest
+	if err := bindBody(context, &sample); err != nil {
+		respondInvalidBody(context, err)
+		return
+	}
+
+	existingMover.addResponseSample(*sample.Minutes)
+	existingMover.UpdatedAt = now()
+	if err := s.store.Update(existingMover); err != nil {
+		respondStoreError(context, err)
+		return
+	}
+	s.events.publish(moverUpdatedEvent, existingMover)
+	context.JSON(http.StatusOK, outputPrecision(context).mover(existingMover))
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"math"
+	"strconv"
+	"time"
+)
+
+// review is a single review left for a mover. The mover's Rating and ReviewCount stay the
+// running aggregate, reviews keeps every individual review behind it
+type review struct {
+	ID         int       `json:"id"`
+	MoverID    int       `json:"mover_id"`
+	ReviewerID string    `json:"reviewer_id,omitempty"`
+	Rating     float64   `json:"rating"`
+	Weight     float64   `json:"weight"` // How much the review counts towards the average, see addRating
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Bounds and default of a review's weight. A review after a cross-country move can count
+// up to three times as much as a regular one, a single-box delivery half as much
+const (
+	defaultReviewWeight = 1.0
+	minReviewWeight     = 0.5
+	maxReviewWeight     = 3.0
+)
+
+// ratingChange reports a mover whose aggregate was corrected by recomputeRatings
+type ratingChange struct {
+	ID                int     `json:"id"`
+	RatingBefore      float64 `json:"rating_before"`
+	RatingAfter       float64 `json:"rating_after"`
+	ReviewCountBefore int     `json:"review_count_before"`
+	ReviewCountAfter  int     `json:"review_count_after"`
+}
+
+// Running averages and a recomputed mean can differ in the last bits, that is not drift
+const ratingDriftTolerance = 1e-9
+
+// recomputeRatings rebuilds Rating and ReviewCount of every mover, deleted ones too, from its
+// rating baseline plus its stored reviews and returns how many movers were checked and the ones
+// that changed. Movers with neither keep the rating they came with. Running it again changes nothing.
+// Stops at the first failed write, run it through Atomically to undo the earlier ones
+func recomputeRatings(store MoverStore, at time.Time) (int, []ratingChange, error) {
+	changes := []ratingChange{}
+	movers := store.All()
+	for _, m := range movers {
+		reviews := store.Reviews(m.ID)
+		weightedSum, totalWeight := m.BaselineRatingSum, m.BaselineRatingWeight
+		for _, review := range reviews {
+			weightedSum += review.Rating * review.Weight
+			totalWeight += review.Weight
+		}
+		if totalWeight <= ratingDriftTolerance {
+			continue
+		}
+		rating := weightedSum / totalWeight
+		reviewCount := m.BaselineReviewCount + len(reviews)
+		if math.Abs(rating-m.Rating) <= ratingDriftTolerance && reviewCount == m.ReviewCount {
+			continue
+		}
+
+		changes = append(changes, ratingChange{
+			ID:                m.ID,
+			RatingBefore:      m.Rating,
+			RatingAfter:       rating,
+			ReviewCountBefore: m.ReviewCount,
+			ReviewCountAfter:  reviewCount,
+		})
+		m.Rating = rating
+		m.ReviewCount = reviewCount
+		m.RatingSum, m.RatingWeight = weightedSum, totalWeight
+		m.UpdatedAt = at
+		if err := store.Update(m); err != nil {
+			return 0, nil, err
+		}
+	}
+	return len(movers), changes, nil
+}
+
+// ratingHistogram counts reviews per star, 1 to 5. Ratings are rounded half-up to whole stars
+// and anything below 1.5 counts as 1 star. Returns the histogram, total and average rating
+func ratingHistogram(reviews []review) (map[string]int, int, float64) {
+	histogram := map[string]int{"1": 0, "2": 0, "3": 0, "4": 0, "5": 0}
+	sum := 0.0
+	for _, review := range reviews {
+		stars := min(max(int(math.Floor(review.Rating+0.5)), 1), 5)
+		histogram[strconv.Itoa(stars)]++
+		sum += review.Rating
+	}
+	if len(reviews) == 0 {
+		return histogram, 0, 0
+	}
+	return histogram, len(reviews), sum / float64(len(reviews))
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultReviewDedupWindow = 60 * time.Second
+
+// recentReview is a review submission kept for the dedup window, with the mover it responded with
+type recentReview struct {
+	rating    float64
+	weight    float64
+	response  mover
+	expiresAt time.Time
+}
+
+// reviewDedupCache remembers recent reviews per mover and submitter, so a review retried by a
+// flaky client isn't recorded twice. Narrower than Idempotency-Key, it needs nothing from the client
+type reviewDedupCache struct {
+	lock    sync.Mutex
+	window  time.Duration
+	reviews map[string]recentReview
+}
+
+func newReviewDedupCache(window time.Duration) *reviewDedupCache {
+	return &reviewDedupCache{window: window, reviews: map[string]recentReview{}}
+}
+
+// reviewDedupKey identifies the submitter by reviewer ID, or by IP for anonymous reviews
+func reviewDedupKey(context *gin.Context, moverId int, reviewerId string) string {
+	// Canonical like HasReviewFrom compares, so "Alice" and " alice" are the same submitter
+	if reviewerId = strings.ToLower(strings.TrimSpace(reviewerId)); reviewerId != "" {
+		return fmt.Sprintf("%d/reviewer:%s", moverId, reviewerId)
+	}
+	return fmt.Sprintf("%d/ip:%s", moverId, context.ClientIP())
+}
+
+// get returns the response to an identical review submitted within the window
+func (cache *reviewDedupCache) get(key string, rating, weight float64, at time.Time) (mover, bool) {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	recent, found := cache.reviews[key]
+	if !found || at.After(recent.expiresAt) || recent.rating != rating || recent.weight != weight {
+		return mover{}, false
+	}
+	return recent.response, true
+}
+
+// put remembers a recorded review and drops expired ones. A window of 0 disables the dedup
+func (cache *reviewDedupCache) put(key string, rating, weight float64, response mover, at time.Time) {
+	if cache.window == 0 {
+		return
+	}
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	for storedKey, stored := range cache.reviews {
+		if at.After(stored.expiresAt) {
+			delete(cache.reviews, storedKey)
+		}
+	}
+	cache.reviews[key] = recentReview{rating: rating, weight: weight, response: response, expiresAt: at.Add(cache.window)}
+}
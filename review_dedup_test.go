@@ -0,0 +1,51 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestDoubleSubmittedReviewIsRecordedOnce(t *testing.T) {
+	for _, rejectDuplicates := range []bool{true, false} {
+		config := testConfig()
+		config.RejectDuplicateReviewers = rejectDuplicates
+		store := newTestStore(t)
+		router := initializeRouter(config, store)
+
+		first := doRequest(router, http.MethodPost, "/v1/movers/1/review", `{"rating": 4, "reviewer_id": "Alice"}`)
+		expectStatus(t, first, http.StatusOK)
+		// The retry differs only in casing and padding of the reviewer ID
+		retry := doRequest(router, http.MethodPost, "/v1/movers/1/review", `{"rating": 4, "reviewer_id": " alice "}`)
+		expectStatus(t, retry, http.StatusOK)
+
+		if retry.Header().Get("Idempotent-Replayed") != "true" {
+			t.Errorf("reject duplicates %v: retry wasn't replayed, headers %v", rejectDuplicates, retry.Header())
+		}
+		if retry.Body.String() != first.Body.String() {
+			t.Errorf("reject duplicates %v: replay %s differs from the first response %s", rejectDuplicates, retry.Body.String(), first.Body.String())
+		}
+		if reviews := store.Reviews(1); len(reviews) != 1 {
+			t.Errorf("reject duplicates %v: %d reviews stored, want 1", rejectDuplicates, len(reviews))
+		}
+		if m, _ := store.Get(1); m.ReviewCount != defaultMovers()[0].ReviewCount+1 {
+			t.Errorf("reject duplicates %v: review_count %d, want %d", rejectDuplicates, m.ReviewCount, defaultMovers()[0].ReviewCount+1)
+		}
+	}
+}
+
+func TestDifferentReviewFromSameSubmitterIsNotReplayed(t *testing.T) {
+	config := testConfig()
+	config.RejectDuplicateReviewers = false
+	store := newTestStore(t)
+	router := initializeRouter(config, store)
+
+	expectStatus(t, doRequest(router, http.MethodPost, "/v1/movers/1/review", `{"rating": 4, "reviewer_id": "bob"}`), http.StatusOK)
+	second := doRequest(router, http.MethodPost, "/v1/movers/1/review", `{"rating": 2, "reviewer_id": "BOB"}`)
+	expectStatus(t, second, http.StatusOK)
+	if second.Header().Get("Idempotent-Replayed") != "" {
+		t.Error("a review with another rating was replayed")
+	}
+	if reviews := store.Reviews(1); len(reviews) != 2 {
+		t.Errorf("%d reviews stored, want 2", len(reviews))
+	}
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+type recomputeResponse struct {
+	Checked int            `json:"checked"`
+	Changed []ratingChange `json:"changed"`
+}
+
+// Seed movers have aggregates without individual reviews behind them, recomputing keeps those
+func TestRecomputeKeepsSeedAggregates(t *testing.T) {
+	for name, openStore := range storeBackends {
+		t.Run(name, func(t *testing.T) {
+			router := initializeRouter(testConfig(), openStore(t))
+			seed := defaultMovers()[2] // Reliable Relocations, 4.7 over 731 reviews
+
+			expectStatus(t, doRequest(router, http.MethodPost, "/v1/movers/3/review", `{"rating": 5, "reviewer_id": "erin"}`), http.StatusOK)
+			reviewed := decode[mover](t, doRequest(router, http.MethodGet, "/v1/movers/3?precision=full", ""))
+
+			recorder := adminRequest(router, http.MethodPost, "/v1/movers/recompute", "")
+			expectStatus(t, recorder, http.StatusOK)
+			if changed := decode[recomputeResponse](t, recorder).Changed; len(changed) != 0 {
+				t.Errorf("recompute changed movers whose totals were right: %+v", changed)
+			}
+
+			recomputed := decode[mover](t, doRequest(router, http.MethodGet, "/v1/movers/3?precision=full", ""))
+			if recomputed.ReviewCount != seed.ReviewCount+1 {
+				t.Errorf("review_count %d after recompute, want %d", recomputed.ReviewCount, seed.ReviewCount+1)
+			}
+			if math.Abs(recomputed.Rating-reviewed.Rating) > 1e-9 {
+				t.Errorf("rating %v after recompute, want %v", recomputed.Rating, reviewed.Rating)
+			}
+		})
+	}
+}
+
+func TestRecomputeCorrectsDriftAboveTheBaseline(t *testing.T) {
+	store := newTestStore(t)
+	router := initializeRouter(testConfig(), store)
+	expectStatus(t, doRequest(router, http.MethodPost, "/v1/movers/3/review", `{"rating": 5}`), http.StatusOK)
+
+	// A manual data edit that skewed the stored aggregate
+	drifted, _ := store.Get(3)
+	drifted.Rating, drifted.ReviewCount = 1.0, 5
+	if err := store.Update(drifted); err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := adminRequest(router, http.MethodPost, "/v1/movers/recompute", "")
+	expectStatus(t, recorder, http.StatusOK)
+	changed := decode[recomputeResponse](t, recorder).Changed
+	if len(changed) != 1 || changed[0].ID != 3 || changed[0].ReviewCountAfter != 732 {
+		t.Fatalf("changed %+v, want mover 3 back at 732 reviews", changed)
+	}
+	want := (4.7*731 + 5) / 732
+	if fixed, _ := store.Get(3); math.Abs(fixed.Rating-want) > 1e-9 {
+		t.Errorf("rating %v after recompute, want %v", fixed.Rating, want)
+	}
+
+	// Idempotent
+	recorder = adminRequest(router, http.MethodPost, "/v1/movers/recompute", "")
+	if changed := decode[recomputeResponse](t, recorder).Changed; len(changed) != 0 {
+		t.Errorf("second recompute changed %+v", changed)
+	}
+}
+
+// Databases written before baselines were tracked get one backfilled when they are opened
+func TestSQLiteBackfillsRatingBaselines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "movers.db")
+	store, err := newSQLiteStore(path, defaultMovers())
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := initializeRouter(testConfig(), store)
+	expectStatus(t, doRequest(router, http.MethodPost, "/v1/movers/3/review", `{"rating": 5}`), http.StatusOK)
+	if _, err := store.db.Exec(`UPDATE movers SET baseline_rating_sum = 0, baseline_rating_weight = 0, baseline_review_count = 0`); err != nil {
+		t.Fatal(err)
+	}
+	_ = store.db.Close()
+
+	reopened, err := newSQLiteStore(path, defaultMovers())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = reopened.db.Close() })
+	m, _ := reopened.Get(3)
+	if m.BaselineReviewCount != 731 || math.Abs(m.BaselineRatingSum-4.7*731) > 1e-6 {
+		t.Errorf("baseline %v over %d reviews, want %v over 731", m.BaselineRatingSum, m.BaselineReviewCount, 4.7*731)
+	}
+
+	recorder := adminRequest(initializeRouter(testConfig(), reopened), http.MethodPost, "/v1/movers/recompute", "")
+	if changed := decode[recomputeResponse](t, recorder).Changed; len(changed) != 0 {
+		t.Errorf("recompute after the backfill changed %+v", changed)
+	}
+}
+
+// Simultaneous reviews of one mover are all counted and the average stays exact, run with -race
+func TestConcurrentReviewsAreAllCounted(t *testing.T) {
+	const reviewers = 50
+	for name, openStore := range storeBackends {
+		t.Run(name, func(t *testing.T) {
+			store := openStore(t)
+			router := initializeRouter(testConfig(), store)
+			before, _ := store.Get(1)
+			id := addTestMover(t, router, store, "Popular Movers", "+15550300001", 0, 0)
+
+			var wait sync.WaitGroup
+			statuses := make(chan int, reviewers)
+			sum := 0.0
+			for i := 0; i < reviewers; i++ {
+				rating := float64(i%5 + 1)
+				sum += rating
+				wait.Add(1)
+				go func() {
+					defer wait.Done()
+					body := fmt.Sprintf(`{"rating": %v, "reviewer_id": "reviewer-%d"}`, rating, i)
+					statuses <- doRequest(router, http.MethodPost, fmt.Sprintf("/v1/movers/%d/review", id), body).Code
+				}()
+			}
+			wait.Wait()
+			close(statuses)
+			for status := range statuses {
+				if status != http.StatusOK {
+					t.Fatalf("a concurrent review got status %d", status)
+				}
+			}
+
+			reviewed, _ := store.Get(id)
+			if reviewed.ReviewCount != reviewers || reviewed.JobsAmount != reviewers {
+				t.Errorf("review_count %d and jobs_done %d, want %d", reviewed.ReviewCount, reviewed.JobsAmount, reviewers)
+			}
+			if want := sum / reviewers; math.Abs(reviewed.Rating-want) > 1e-9 {
+				t.Errorf("rating %v, want the true mean %v", reviewed.Rating, want)
+			}
+			if stored := store.Reviews(id); len(stored) != reviewers {
+				t.Errorf("%d reviews stored, want %d", len(stored), reviewers)
+			}
+			if after, _ := store.Get(1); after.ReviewCount != before.ReviewCount {
+				t.Errorf("another mover's review_count changed from %d to %d", before.ReviewCount, after.ReviewCount)
+			}
+		})
+	}
+}
+
+func TestDuplicateReviewerIsRejected(t *testing.T) {
+	for _, rejectDuplicates := range []bool{true, false} {
+		t.Run(fmt.Sprintf("reject=%t", rejectDuplicates), func(t *testing.T) {
+			config := testConfig()
+			config.RejectDuplicateReviewers = rejectDuplicates
+			store := newTestStore(t)
+			router := initializeRouter(config, store)
+
+			expectStatus(t, doRequest(router, http.MethodPost, "/v1/movers/1/review", `{"rating": 1, "reviewer_id": "bomber"}`), http.StatusOK)
+			second := http.StatusConflict
+			if !rejectDuplicates {
+				second = http.StatusOK
+			}
+			expectStatus(t, doRequest(router, http.MethodPost, "/v1/movers/1/review", `{"rating": 2, "reviewer_id": "bomber"}`), second)
+
+			// Other movers, other reviewers and anonymous reviews aren't affected
+			expectStatus(t, doRequest(router, http.MethodPost, "/v1/movers/2/review", `{"rating": 1, "reviewer_id": "bomber"}`), http.StatusOK)
+			expectStatus(t, doRequest(router, http.MethodPost, "/v1/movers/1/review", `{"rating": 5, "reviewer_id": "someone else"}`), http.StatusOK)
+			expectStatus(t, doRequest(router, http.MethodPost, "/v1/movers/1/review", `{"rating": 4}`), http.StatusOK)
+			expectStatus(t, doRequest(router, http.MethodPost, "/v1/movers/1/review", `{"rating": 3}`), http.StatusOK)
+
+			fromBomber := 0
+			for _, stored := range store.Reviews(1) {
+				if stored.ReviewerID == "bomber" {
+					fromBomber++
+				}
+			}
+			if want := map[bool]int{true: 1, false: 2}[rejectDuplicates]; fromBomber != want {
+				t.Errorf("%d reviews from the same reviewer stored, want %d", fromBomber, want)
+			}
+		})
+	}
+}
+
+func TestRejectDuplicateReviewersFromEnv(t *testing.T) {
+	for value, want := range map[string]bool{"": true, "true": true, "false": false} {
+		t.Setenv("REJECT_DUPLICATE_REVIEWERS", value)
+		config, err := LoadConfig()
+		if err != nil || config.RejectDuplicateReviewers != want {
+			t.Errorf("REJECT_DUPLICATE_REVIEWERS=%q: got %t, %v, want %t", value, config.RejectDuplicateReviewers, err, want)
+		}
+	}
+	t.Setenv("REJECT_DUPLICATE_REVIEWERS", "sometimes")
+	if _, err := LoadConfig(); err == nil {
+		t.Error("REJECT_DUPLICATE_REVIEWERS=sometimes was accepted")
+	}
+}
+
+func TestDeletingMiddleReviewRecomputesAverage(t *testing.T) {
+	store := newTestStore(t)
+	router := initializeRouter(testConfig(), store)
+	id := addTestMover(t, router, store, "Reviewed Movers", "+15551300001", 0, 0)
+	for _, rating := range []string{"5", "1", "3"} {
+		expectStatus(t, doRequest(router, http.MethodPost, fmt.Sprintf("/v1/movers/%d/review", id), `{"rating": `+rating+`}`), http.StatusOK)
+	}
+	reviews := store.Reviews(id)
+	if len(reviews) != 3 {
+		t.Fatalf("%d reviews stored, want 3", len(reviews))
+	}
+	path := fmt.Sprintf("/v1/movers/%d/reviews/%d", id, reviews[1].ID)
+
+	expectStatus(t, doRequest(router, http.MethodDelete, path, ""), http.StatusUnauthorized)
+	recorder := adminRequest(router, http.MethodDelete, path, "")
+	expectStatus(t, recorder, http.StatusOK)
+
+	// (5 + 3) / 2, the 1 star review is gone
+	got := decode[mover](t, doRequest(router, http.MethodGet, fmt.Sprintf("/v1/movers/%d?precision=full", id), ""))
+	if got.Rating != 4 || got.ReviewCount != 2 {
+		t.Errorf("after the delete rating %v and review_count %d, want 4 and 2", got.Rating, got.ReviewCount)
+	}
+	if remaining := store.Reviews(id); len(remaining) != 2 || remaining[0].Rating != 5 || remaining[1].Rating != 3 {
+		t.Errorf("remaining reviews %+v, want the 5 and 3 star ones", remaining)
+	}
+
+	expectStatus(t, adminRequest(router, http.MethodDelete, path, ""), http.StatusNotFound)
+	expectStatus(t, adminRequest(router, http.MethodDelete, fmt.Sprintf("/v1/movers/999/reviews/%d", reviews[0].ID), ""), http.StatusNotFound)
+}
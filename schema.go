@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// Patterns of the nocontrol tag, on its own and combined with notblank
+const (
+	noControlSchemaPattern         = `^[^\x00-\x1F\x7F]*$`
+	noControlNotBlankSchemaPattern = `^[^\x00-\x1F\x7F]*[^\x00-\x20\x7F][^\x00-\x1F\x7F]*$`
+)
+
+// telNumberSchemaPattern accepts what the telephone validator accepts: telNumberPattern once the
+// formatting characters normalizeTelNumber strips are gone
+const telNumberSchemaPattern = `^[ ().-]*\+[ ().-]*[1-9]([ ().-]*[0-9]){1,14}[ ().-]*$`
+
+// Mover fields the server sets itself, sent values are ignored
+var moverReadOnlyFields = map[string]bool{
+	"id": true, "verified": true, "featured": true, "avg_response_minutes": true, "response_samples": true, "review_count": true, "created_at": true, "updated_at": true,
+}
+
+// Rules the handlers check outside the binding tags, see normalizeMoverInput
+var moverSchemaRules = map[string]map[string]any{
+	"services": {"items": map[string]any{"type": "string", "minLength": 1, "maxLength": maxServiceLength, "pattern": `\S`}},
+	"weekday":  {"enum": weekdayNames()},
+	"start":    {"pattern": `^([01][0-9]|2[0-3]):[0-5][0-9]$`, "description": "HH:MM, before end"},
+	"end":      {"pattern": `^([01][0-9]|2[0-3]):[0-5][0-9]$`, "description": "HH:MM, exclusive"},
+}
+
+func weekdayNames() []string {
+	names := []string{}
+	for name := range weekdays {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// structSchema builds the JSON Schema of a request body struct from its json and binding tags,
+// the same tags the handlers validate with, so the published schema can't drift from what is accepted
+func structSchema(t reflect.Type, readOnly map[string]bool, rules map[string]map[string]any) map[string]any {
+	properties := map[string]any{}
+	required := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		property := typeSchema(field.Type, readOnly, rules)
+		for _, rule := range strings.Split(field.Tag.Get("binding"), ",") {
+			key, value, _ := strings.Cut(rule, "=")
+			switch key {
+			case "required":
+				required = append(required, name)
+			case "gt":
+				property["exclusiveMinimum"], _ = strconv.ParseFloat(value, 64)
+			case "gte":
+				property["minimum"], _ = strconv.ParseFloat(value, 64)
+			case "lte":
+				property["maximum"], _ = strconv.ParseFloat(value, 64)
+			case "max":
+				property["maxLength"], _ = strconv.Atoi(value)
+			case "notblank":
+				property["pattern"] = `\S`
+			case "telephone":
+				property["pattern"] = telNumberSchemaPattern
+			case "nocontrol":
+				if property["pattern"] == `\S` {
+					property["pattern"] = noControlNotBlankSchemaPattern
+				} else {
+					property["pattern"] = noControlSchemaPattern
+				}
+			}
+		}
+		for key, value := range rules[name] {
+			property[key] = value
+		}
+		if readOnly[name] {
+			property["readOnly"] = true
+		}
+		properties[name] = property
+	}
+
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func typeSchema(t reflect.Type, readOnly map[string]bool, rules map[string]map[string]any) map[string]any {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == reflect.TypeOf(time.Time{}):
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.String:
+		return map[string]any{"type": "string"}
+	case t.Kind() == reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		return map[string]any{"type": "number"}
+	case t.Kind() == reflect.Slice:
+		return map[string]any{"type": "array", "items": typeSchema(t.Elem(), readOnly, rules)}
+	case t.Kind() == reflect.Struct:
+		return structSchema(t, readOnly, rules)
+	}
+	return map[string]any{}
+}
+
+// moverSchema is the body of POST /movers and PUT /movers/:id
+func moverSchema() map[string]any {
+	schema := structSchema(reflect.TypeOf(mover{}), moverReadOnlyFields, moverSchemaRules)
+	schema["$schema"] = jsonSchemaDialect
+	schema["$id"] = "/schema/mover.json"
+	schema["title"] = "Mover"
+	return schema
+}
+
+// reviewSchema is the body of POST /movers/:id/review. bindReview checks the review by hand,
+// so its schema is built from the same constants
+func reviewSchema() map[string]any {
+	return map[string]any{
+		"$schema": jsonSchemaDialect,
+		"$id":     "/schema/review.json",
+		"title":   "Review",
+		"type":    "object",
+		"properties": map[string]any{
+			"rating":      map[string]any{"type": "number", "minimum": 0, "maximum": 5},
+			"reviewer_id": map[string]any{"type": "string", "pattern": noControlSchemaPattern, "description": "Optional, one review per reviewer and mover"},
+			"weight":      map[string]any{"type": "number", "minimum": minReviewWeight, "maximum": maxReviewWeight, "default": defaultReviewWeight},
+		},
+		"required": []string{"rating"},
+	}
+}
+
+// GET request. Serve a JSON Schema, encoded once when the route is registered
+func getSchema(schema map[string]any) gin.HandlerFunc {
+	body, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		panic(err)
+	}
+	return func(context *gin.Context) {
+		context.Data(http.StatusOK, "application/schema+json", body)
+	}
+}
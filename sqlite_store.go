@@ -0,0 +1,545 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/mattn/go-sqlite3"
+	"strings"
+	"sync"
+	"time"
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS movers (
+	id                   INTEGER PRIMARY KEY,
+	name                 TEXT    NOT NULL,
+	rating               REAL    NOT NULL,
+	telephone_number     TEXT    NOT NULL,
+	jobs_done            INTEGER NOT NULL,
+	review_count         INTEGER NOT NULL,
+	latitude             REAL    NOT NULL,
+	longitude            REAL    NOT NULL,
+	hourly_rate          REAL    NOT NULL,
+	services             TEXT    NOT NULL, -- JSON array
+	availability         TEXT    NOT NULL, -- JSON array
+	created_at           TEXT    NOT NULL, -- RFC3339
+	updated_at           TEXT    NOT NULL,
+	deleted              INTEGER NOT NULL DEFAULT 0,
+	featured             INTEGER NOT NULL DEFAULT 0,
+	verified             INTEGER NOT NULL DEFAULT 0,
+	rating_sum           REAL    NOT NULL DEFAULT 0,
+	rating_weight        REAL    NOT NULL DEFAULT 0,
+	avg_response_minutes REAL    NOT NULL DEFAULT 0,
+	response_samples     INTEGER NOT NULL DEFAULT 0,
+	response_minutes_sum REAL    NOT NULL DEFAULT 0,
+	baseline_rating_sum    REAL    NOT NULL DEFAULT 0,
+	baseline_rating_weight REAL    NOT NULL DEFAULT 0,
+	baseline_review_count  INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS movers_telephone_number ON movers (telephone_number);
+
+CREATE TABLE IF NOT EXISTS reviews (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	mover_id    INTEGER NOT NULL REFERENCES movers (id),
+	reviewer_id TEXT    NOT NULL,
+	rating      REAL    NOT NULL,
+	weight      REAL    NOT NULL,
+	created_at  TEXT    NOT NULL
+);
+CREATE INDEX IF NOT EXISTS reviews_mover_id ON reviews (mover_id);
+`
+
+const moverColumns = `id, name, rating, telephone_number, jobs_done, review_count, latitude, longitude,
+	hourly_rate, services, availability, created_at, updated_at, deleted, featured,
+	verified, rating_sum, rating_weight, avg_response_minutes, response_samples, response_minutes_sum,
+	baseline_rating_sum, baseline_rating_weight, baseline_review_count`
+
+// sqliteStore keeps movers and reviews in a SQLite database, so they survive restarts.
+// The lock still serializes requests like memoryStore does, SQLite allows a single writer anyway.
+// Writes return their errors and Atomically runs them in a transaction. A write failing because
+// the database is busy isn't retried here, holding the lock, writeLocked retries the request.
+// Failed reads panic, the recovery middleware answers them with 500
+type sqliteStore struct {
+	sync.RWMutex
+	db *sql.DB
+	tx *sql.Tx // open while Atomically runs, only set and read under the store lock
+}
+
+const defaultStoreWriteRetries = 3
+
+// First wait before retrying a request whose write failed transiently, doubled on every further attempt
+const storeRetryBackoff = 25 * time.Millisecond
+
+// isTransient reports whether a write failed only because the database was busy or locked,
+// e.g. by another process using the file, so the same write can succeed a moment later
+func isTransient(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && (sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked)
+}
+
+// exec runs a write statement in the open transaction if there is one
+func (store *sqliteStore) exec(query string, args ...any) (sql.Result, error) {
+	return store.conn().Exec(query, args...)
+}
+
+// sqlRunner is what *sql.DB and *sql.Tx have in common
+type sqlRunner interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// conn runs statements in the open transaction if there is one. With a single connection,
+// a statement outside the transaction would wait for it forever
+func (store *sqliteStore) conn() sqlRunner {
+	if store.tx != nil {
+		return store.tx
+	}
+	return store.db
+}
+
+// newSQLiteStore opens or creates the database at path. An empty database is seeded with the given movers
+func newSQLiteStore(path string, seed []mover) (*sqliteStore, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+	// One connection, so every statement sees the changes of the previous ones
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("create schema: %w", err)
+	}
+	if err := migrateSQLite(db); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("migrate schema: %w", err)
+	}
+
+	store := &sqliteStore{db: db}
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM movers`).Scan(&count); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	if count == 0 {
+		err := store.Atomically(func() error {
+			for _, seedMover := range seed {
+				seedMover.setRatingBaseline(nil)
+				if err := store.insert(seedMover); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			_ = db.Close()
+			return nil, fmt.Errorf("seed movers: %w", err)
+		}
+	}
+	if err := store.Atomically(store.backfillRatingBaselines); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("backfill rating baselines: %w", err)
+	}
+	return store, nil
+}
+
+// backfillRatingBaselines sets the baseline of movers stored before it was tracked, their reviews
+// beyond the stored ones. Movers whose reviews are all stored keep a zero baseline, so it's safe on every open
+func (store *sqliteStore) backfillRatingBaselines() error {
+	candidates := store.queryMovers(`SELECT ` + moverColumns + ` FROM movers WHERE baseline_review_count = 0 AND review_count > 0`)
+	for _, m := range candidates {
+		m.setRatingBaseline(store.Reviews(m.ID))
+		if m.BaselineReviewCount == 0 {
+			continue
+		}
+		if err := store.Update(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sqliteMigrations add the columns introduced after the first schema to existing databases
+var sqliteMigrations = []struct{ table, column, definition string }{
+	{"movers", "featured", "INTEGER NOT NULL DEFAULT 0"},
+	{"movers", "rating_sum", "REAL NOT NULL DEFAULT 0"},
+	{"movers", "rating_weight", "REAL NOT NULL DEFAULT 0"},
+	{"movers", "verified", "INTEGER NOT NULL DEFAULT 0"},
+	{"movers", "avg_response_minutes", "REAL NOT NULL DEFAULT 0"},
+	{"movers", "response_samples", "INTEGER NOT NULL DEFAULT 0"},
+	{"movers", "response_minutes_sum", "REAL NOT NULL DEFAULT 0"},
+	{"movers", "baseline_rating_sum", "REAL NOT NULL DEFAULT 0"},
+	{"movers", "baseline_rating_weight", "REAL NOT NULL DEFAULT 0"},
+	{"movers", "baseline_review_count", "INTEGER NOT NULL DEFAULT 0"},
+}
+
+func migrateSQLite(db *sql.DB) error {
+	for _, migration := range sqliteMigrations {
+		var exists bool
+		err := db.QueryRow(`SELECT EXISTS (SELECT 1 FROM pragma_table_info(?) WHERE name = ?)`, migration.table, migration.column).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", migration.table, migration.column, migration.definition)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// must turns storage errors into panics, see sqliteStore
+func must(err error) {
+	if err != nil {
+		panic(fmt.Errorf("sqlite store: %w", err))
+	}
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanMover(row rowScanner) (mover, error) {
+	var m mover
+	var services, availability, createdAt, updatedAt string
+	err := row.Scan(&m.ID, &m.Name, &m.Rating, &m.TelephoneNumber, &m.JobsAmount, &m.ReviewCount,
+		&m.Latitude, &m.Longitude, &m.HourlyRate, &services, &availability, &createdAt, &updatedAt, &m.Deleted, &m.Featured, &m.Verified, &m.RatingSum, &m.RatingWeight,
+		&m.AvgResponseMinutes, &m.ResponseSamples, &m.ResponseMinutesSum,
+		&m.BaselineRatingSum, &m.BaselineRatingWeight, &m.BaselineReviewCount)
+	if err != nil {
+		return mover{}, err
+	}
+	must(json.Unmarshal([]byte(services), &m.Services))
+	must(json.Unmarshal([]byte(availability), &m.Availability))
+	m.CreatedAt, err = time.Parse(time.RFC3339Nano, createdAt)
+	must(err)
+	m.UpdatedAt, err = time.Parse(time.RFC3339Nano, updatedAt)
+	must(err)
+	return m, nil
+}
+
+func (store *sqliteStore) queryMovers(query string, args ...any) []mover {
+	rows, err := store.conn().Query(query, args...)
+	must(err)
+	defer rows.Close()
+
+	movers := []mover{}
+	for rows.Next() {
+		m, err := scanMover(rows)
+		must(err)
+		movers = append(movers, m)
+	}
+	must(rows.Err())
+	return movers
+}
+
+func (store *sqliteStore) queryMover(query string, args ...any) (mover, error) {
+	m, err := scanMover(store.conn().QueryRow(query, args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return mover{}, errMoverNotFound
+	}
+	must(err)
+	return m, nil
+}
+
+// moverValues are the column values of a mover, in moverColumns order without the ID
+func moverValues(m mover) ([]any, error) {
+	services, err := json.Marshal(nonNil(m.Services))
+	if err != nil {
+		return nil, err
+	}
+	availability, err := json.Marshal(nonNil(m.Availability))
+	if err != nil {
+		return nil, err
+	}
+	return []any{m.Name, m.Rating, normalizeTelNumber(m.TelephoneNumber), m.JobsAmount, m.ReviewCount,
+		m.Latitude, m.Longitude, m.HourlyRate, string(services), string(availability),
+		m.CreatedAt.Format(time.RFC3339Nano), m.UpdatedAt.Format(time.RFC3339Nano), m.Deleted, m.Featured, m.Verified, m.RatingSum, m.RatingWeight,
+		m.AvgResponseMinutes, m.ResponseSamples, m.ResponseMinutesSum,
+		m.BaselineRatingSum, m.BaselineRatingWeight, m.BaselineReviewCount}, nil
+}
+
+// nonNil stores empty lists as [] rather than null
+func nonNil[T any](values []T) []T {
+	if values == nil {
+		return []T{}
+	}
+	return values
+}
+
+func (store *sqliteStore) insert(m mover) error {
+	values, err := moverValues(m)
+	if err != nil {
+		return err
+	}
+	_, err = store.exec(`INSERT INTO movers (`+moverColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		append([]any{m.ID}, values...)...)
+	return err
+}
+
+func (store *sqliteStore) All() []mover {
+	return store.queryMovers(`SELECT ` + moverColumns + ` FROM movers ORDER BY id`)
+}
+
+func (store *sqliteStore) List() []mover {
+	return store.queryMovers(`SELECT ` + moverColumns + ` FROM movers WHERE deleted = 0 ORDER BY id`)
+}
+
+// ListMatching pushes the filters of GET /movers into the WHERE clause, so only matching rows
+// are loaded. Sorting stays in Go, the ranking needs the mean rating of all movers
+func (store *sqliteStore) ListMatching(options listOptions) []mover {
+	conditions, args := []string{"deleted = 0"}, []any{}
+	// SQLite lower() only folds ASCII, other names and fuzzy matching are left to listOptions.matches
+	if options.Name != "" && isASCII(options.Name) && !options.Fuzzy {
+		conditions = append(conditions, "instr(lower(name), lower(?)) > 0")
+		args = append(args, options.Name)
+	}
+	addBound := func(condition string, bound any) {
+		conditions = append(conditions, condition)
+		args = append(args, bound)
+	}
+	if options.MinRating != nil {
+		addBound("rating >= ?", *options.MinRating)
+	}
+	if options.MinRate != nil {
+		addBound("hourly_rate >= ?", *options.MinRate)
+	}
+	if options.MaxRate != nil {
+		addBound("hourly_rate <= ?", *options.MaxRate)
+	}
+	if options.MinJobs != nil {
+		addBound("jobs_done >= ?", *options.MinJobs)
+	}
+	if options.MaxJobs != nil {
+		addBound("jobs_done <= ?", *options.MaxJobs)
+	}
+	if options.Featured != nil {
+		addBound("featured = ?", *options.Featured)
+	}
+	if options.Verified != nil {
+		addBound("verified = ?", *options.Verified)
+	}
+	for _, service := range options.Services {
+		addBound("EXISTS (SELECT 1 FROM json_each(services) WHERE value = ?)", service)
+	}
+	return store.queryMovers(`SELECT `+moverColumns+` FROM movers WHERE `+strings.Join(conditions, " AND ")+` ORDER BY id`, args...)
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+func (store *sqliteStore) Get(id int) (mover, error) {
+	return store.queryMover(`SELECT `+moverColumns+` FROM movers WHERE id = ? AND deleted = 0`, id)
+}
+
+func (store *sqliteStore) Find(id int) (mover, error) {
+	return store.queryMover(`SELECT `+moverColumns+` FROM movers WHERE id = ?`, id)
+}
+
+func (store *sqliteStore) ByTelNumber(telNumber string) (mover, error) {
+	return store.queryMover(`SELECT `+moverColumns+` FROM movers WHERE telephone_number = ? AND deleted = 0`,
+		normalizeTelNumber(telNumber))
+}
+
+// NameTaken compares names in Go, like memoryStore, because SQLite can't fold non-ASCII case
+func (store *sqliteStore) NameTaken(name string, excludeID int) bool {
+	rows, err := store.conn().Query(`SELECT name FROM movers WHERE id != ?`, excludeID)
+	must(err)
+	defer rows.Close()
+	for rows.Next() {
+		var existingName string
+		must(rows.Scan(&existingName))
+		if sameName(existingName, name) {
+			return true
+		}
+	}
+	must(rows.Err())
+	return false
+}
+
+// TelNumberTaken includes soft-deleted movers, so a restore can't collide with a newer mover
+func (store *sqliteStore) TelNumberTaken(telNumber string, excludeID int) bool {
+	var taken bool
+	must(store.conn().QueryRow(`SELECT EXISTS (SELECT 1 FROM movers WHERE telephone_number = ? AND id != ?)`,
+		normalizeTelNumber(telNumber), excludeID).Scan(&taken))
+	return taken
+}
+
+// Add inserts the movers in one transaction, a failed insert leaves none of them behind
+func (store *sqliteStore) Add(movers ...mover) ([]mover, error) {
+	added := make([]mover, len(movers))
+	err := store.Atomically(func() error {
+		var nextId int
+		if err := store.conn().QueryRow(`SELECT COALESCE(MAX(id), 0) + 1 FROM movers`).Scan(&nextId); err != nil {
+			return err
+		}
+		for i, newMover := range movers {
+			newMover.ID = nextId + i
+			newMover.TelephoneNumber = normalizeTelNumber(newMover.TelephoneNumber)
+			if err := store.insert(newMover); err != nil {
+				return err
+			}
+			added[i] = newMover
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return added, nil
+}
+
+func (store *sqliteStore) Update(m mover) error {
+	values, err := moverValues(m)
+	if err != nil {
+		return err
+	}
+	result, err := store.exec(`UPDATE movers SET name = ?, rating = ?, telephone_number = ?, jobs_done = ?,
+		review_count = ?, latitude = ?, longitude = ?, hourly_rate = ?, services = ?, availability = ?,
+		created_at = ?, updated_at = ?, deleted = ?, featured = ?,
+		verified = ?, rating_sum = ?, rating_weight = ?,
+		avg_response_minutes = ?, response_samples = ?, response_minutes_sum = ?,
+		baseline_rating_sum = ?, baseline_rating_weight = ?, baseline_review_count = ? WHERE id = ?`, append(values, m.ID)...)
+	if err != nil {
+		return err
+	}
+	return affectedOne(result)
+}
+
+func (store *sqliteStore) Delete(id int, at time.Time) error {
+	result, err := store.exec(`UPDATE movers SET deleted = 1, updated_at = ? WHERE id = ? AND deleted = 0`,
+		at.Format(time.RFC3339Nano), id)
+	if err != nil {
+		return err
+	}
+	return affectedOne(result)
+}
+
+// affectedOne returns errMoverNotFound when the statement matched no mover
+func affectedOne(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errMoverNotFound
+	}
+	return nil
+}
+
+func (store *sqliteStore) AddReview(moverId int, reviewerId string, rating, weight float64) (review, error) {
+	newReview := review{
+		MoverID:    moverId,
+		ReviewerID: reviewerId,
+		Rating:     rating,
+		Weight:     weight,
+		CreatedAt:  now(),
+	}
+	result, err := store.exec(`INSERT INTO reviews (mover_id, reviewer_id, rating, weight, created_at) VALUES (?, ?, ?, ?, ?)`,
+		moverId, reviewerId, rating, weight, newReview.CreatedAt.Format(time.RFC3339Nano))
+	if err != nil {
+		return review{}, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return review{}, err
+	}
+	newReview.ID = int(id)
+	return newReview, nil
+}
+
+func (store *sqliteStore) DeleteReview(moverId, reviewId int) (review, error) {
+	var deleted review
+	var createdAt string
+	err := store.conn().QueryRow(`DELETE FROM reviews WHERE id = ? AND mover_id = ?
+		RETURNING id, mover_id, reviewer_id, rating, weight, created_at`, reviewId, moverId).
+		Scan(&deleted.ID, &deleted.MoverID, &deleted.ReviewerID, &deleted.Rating, &deleted.Weight, &createdAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return review{}, errReviewNotFound
+	}
+	if err != nil {
+		return review{}, err
+	}
+	deleted.CreatedAt, err = time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return review{}, err
+	}
+	return deleted, nil
+}
+
+// HasReviewFrom compares reviewer IDs case-insensitively, anonymous reviews never match
+func (store *sqliteStore) HasReviewFrom(moverId int, reviewerId string) bool {
+	if reviewerId == "" {
+		return false
+	}
+	for _, review := range store.Reviews(moverId) {
+		if strings.EqualFold(review.ReviewerID, reviewerId) {
+			return true
+		}
+	}
+	return false
+}
+
+func (store *sqliteStore) Reviews(moverId int) []review {
+	rows, err := store.conn().Query(`SELECT id, mover_id, reviewer_id, rating, weight, created_at FROM reviews
+		WHERE mover_id = ? ORDER BY id`, moverId)
+	must(err)
+	defer rows.Close()
+
+	reviews := []review{}
+	for rows.Next() {
+		var r review
+		var createdAt string
+		must(rows.Scan(&r.ID, &r.MoverID, &r.ReviewerID, &r.Rating, &r.Weight, &createdAt))
+		r.CreatedAt, err = time.Parse(time.RFC3339Nano, createdAt)
+		must(err)
+		reviews = append(reviews, r)
+	}
+	must(rows.Err())
+	return reviews
+}
+
+// MeanRating is the mean rating of the active movers, the prior of the ranking
+func (store *sqliteStore) MeanRating() float64 {
+	var mean float64
+	must(store.conn().QueryRow(`SELECT COALESCE(AVG(rating), 0) FROM movers WHERE deleted = 0`).Scan(&mean))
+	return mean
+}
+
+// Atomically runs fn in a transaction, rolled back when fn fails or panics
+func (store *sqliteStore) Atomically(fn func() error) error {
+	if store.tx != nil {
+		return fn()
+	}
+	tx, err := store.db.BeginTx(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+	store.tx = tx
+	committed := false
+	defer func() {
+		store.tx = nil
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err := fn(); err != nil {
+		return err
+	}
+	committed = true
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// lockedSQLiteStore opens a SQLite store that gives up on a busy database right away, and a second
+// connection holding the database's write lock. The returned func releases the lock
+func lockedSQLiteStore(t *testing.T) (*sqliteStore, func()) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "movers.db")
+	store, err := newSQLiteStore("file:"+path+"?_busy_timeout=0", defaultMovers())
+	if err != nil {
+		t.Fatalf("opening SQLite store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.db.Close() })
+
+	// Another process writing to the same file
+	other, err := sql.Open("sqlite3", "file:"+path+"?_busy_timeout=0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = other.Close() })
+	other.SetMaxOpenConns(1)
+	if _, err := other.Exec(`BEGIN IMMEDIATE`); err != nil {
+		t.Fatalf("locking the database: %v", err)
+	}
+	return store, func() { _, _ = other.Exec(`ROLLBACK`) }
+}
+
+func TestSQLiteRetriesBusyWrites(t *testing.T) {
+	store, unlock := lockedSQLiteStore(t)
+	router := initializeRouter(testConfig(), store)
+	// Released while the request is backing off
+	time.AfterFunc(30*time.Millisecond, unlock)
+
+	recorder := doRequest(router, http.MethodPatch, "/v1/movers/1", `{"hourly_rate": 200}`)
+	expectStatus(t, recorder, http.StatusOK)
+	if updated, _ := store.Get(1); updated.HourlyRate != 200 {
+		t.Errorf("hourly rate %v after the retried update, want 200", updated.HourlyRate)
+	}
+}
+
+// A write backing off doesn't hold the store lock, the other requests aren't stalled
+func TestBusyWriteBacksOffWithoutTheLock(t *testing.T) {
+	store, unlock := lockedSQLiteStore(t)
+	config := testConfig()
+	config.StoreWriteRetries = 5 // backs off for 775ms in total
+	router := initializeRouter(config, store)
+
+	written := make(chan int)
+	go func() {
+		written <- doRequest(router, http.MethodPost, "/v1/movers/1/review", `{"rating": 4}`).Code
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	started := time.Now()
+	expectStatus(t, doRequest(router, http.MethodGet, "/v1/movers/1", ""), http.StatusOK)
+	if waited := time.Since(started); waited > 100*time.Millisecond {
+		t.Errorf("a read waited %v for a write backing off", waited)
+	}
+	select {
+	case status := <-written:
+		t.Fatalf("the write finished with %d while the database was still locked", status)
+	default:
+	}
+
+	unlock()
+	if status := <-written; status != http.StatusOK {
+		t.Errorf("retried write status %d, want 200", status)
+	}
+	if reviews := store.Reviews(1); len(reviews) != 1 {
+		t.Errorf("%d reviews after the retried write, want 1", len(reviews))
+	}
+}
+
+func TestSQLiteBusyWriteRollsBackWhenRetriesRunOut(t *testing.T) {
+	store, unlock := lockedSQLiteStore(t)
+	config := testConfig()
+	config.StoreWriteRetries = 1
+	router := initializeRouter(config, store)
+
+	recorder := doRequest(router, http.MethodPost, "/v1/movers/1/review", `{"rating": 1, "reviewer_id": "dave"}`)
+	expectStatus(t, recorder, http.StatusInternalServerError)
+
+	unlock()
+	if reviews := store.Reviews(1); len(reviews) != 0 {
+		t.Errorf("review kept after the failed write: %v", reviews)
+	}
+	if unchanged, _ := store.Get(1); unchanged.JobsAmount != defaultMovers()[0].JobsAmount {
+		t.Errorf("jobs_done %d after the failed write, want %d", unchanged.JobsAmount, defaultMovers()[0].JobsAmount)
+	}
+
+	// Once the database is free again the same review goes through
+	recorder = doRequest(router, http.MethodPost, "/v1/movers/1/review", `{"rating": 1, "reviewer_id": "dave"}`)
+	expectStatus(t, recorder, http.StatusOK)
+}
@@ -0,0 +1,51 @@
+package main
+
+const ratingBucketWidth = 0.5
+
+// ratingBucket counts movers rated in [min, max). The last bucket also includes 5.0
+type ratingBucket struct {
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Count int     `json:"count"`
+}
+
+type moverStats struct {
+	Total         int            `json:"total"`
+	AverageRating float64        `json:"average_rating"`
+	TotalJobs     int            `json:"total_jobs"`
+	HighestRated  *mover         `json:"highest_rated"` // null when there are no movers
+	LowestRated   *mover         `json:"lowest_rated"`  // null when there are no movers
+	RatingBuckets []ratingBucket `json:"rating_buckets"`
+}
+
+// computeStats summarizes the movers. If ratings are equal, the lower ID wins highest and lowest rated.
+// An empty list gives zeros and empty buckets. Ratings are not rounded
+func computeStats(movers []mover) moverStats {
+	bucketCount := int(5.0 / ratingBucketWidth)
+	stats := moverStats{Total: len(movers), RatingBuckets: make([]ratingBucket, bucketCount)}
+	for i := range stats.RatingBuckets {
+		stats.RatingBuckets[i].Min = float64(i) * ratingBucketWidth
+		stats.RatingBuckets[i].Max = float64(i+1) * ratingBucketWidth
+	}
+
+	ratingSum := 0.0
+	for i, mover := range movers {
+		ratingSum += mover.Rating
+		stats.TotalJobs += mover.JobsAmount
+
+		if stats.HighestRated == nil || mover.Rating > stats.HighestRated.Rating {
+			stats.HighestRated = &movers[i]
+		}
+		if stats.LowestRated == nil || mover.Rating < stats.LowestRated.Rating {
+			stats.LowestRated = &movers[i]
+		}
+
+		bucket := min(max(int(mover.Rating/ratingBucketWidth), 0), bucketCount-1)
+		stats.RatingBuckets[bucket].Count++
+	}
+
+	if len(movers) > 0 {
+		stats.AverageRating = ratingSum / float64(len(movers))
+	}
+	return stats
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+// The status of every handler's error paths, so misused codes can't come back
+func TestErrorStatusCodes(t *testing.T) {
+	tests := []struct {
+		method, path, body string
+		want               int
+	}{
+		// Adding movers
+		{http.MethodPost, "/v1/movers", `{"name": "Rapid Movers", "telephone_number": "+15550400001"}`, http.StatusConflict},
+		{http.MethodPost, "/v1/movers", `{"name": "New Movers", "telephone_number": "+15617384568"}`, http.StatusConflict},
+		{http.MethodPost, "/v1/movers", `{"name": "New Movers"}`, http.StatusBadRequest},
+		{http.MethodPost, "/v1/movers", `{"name": "New Movers", "telephone_number": "not a number"}`, http.StatusBadRequest},
+		{http.MethodPost, "/v1/movers", `{"name": "New Movers", "telephone_number": "+15550400001", "rating": 6}`, http.StatusBadRequest},
+		{http.MethodPost, "/v1/movers", `{"name": `, http.StatusBadRequest},
+		{http.MethodPost, "/v1/movers/bulk", `[{"name": "Rapid Movers", "telephone_number": "+15550400001"}]`, http.StatusBadRequest},
+		{http.MethodPost, "/v1/movers/bulk?mode=sometimes", `[]`, http.StatusBadRequest},
+
+		// Reading movers
+		{http.MethodGet, "/v1/movers/abc", "", http.StatusBadRequest},
+		{http.MethodGet, "/v1/movers/999", "", http.StatusNotFound},
+		{http.MethodGet, "/v1/movers?min_rating=high", "", http.StatusBadRequest},
+		{http.MethodGet, "/v1/movers?sort=unknown", "", http.StatusBadRequest},
+		{http.MethodGet, "/v1/movers?fields=unknown", "", http.StatusBadRequest},
+		{http.MethodGet, "/v1/movers/by-phone/+15550409999", "", http.StatusNotFound},
+		{http.MethodGet, "/v1/movers/nearby", "", http.StatusBadRequest},
+		{http.MethodGet, "/v1/movers/top?n=0", "", http.StatusBadRequest},
+		{http.MethodGet, "/v1/movers/compare?ids=1,x", "", http.StatusBadRequest},
+		{http.MethodGet, "/v1/movers/compare?ids=1,999", "", http.StatusNotFound},
+		{http.MethodGet, "/v1/movers/random?count=0", "", http.StatusBadRequest},
+		{http.MethodGet, "/v1/movers/1/estimate?from_lat=100&from_lng=0&to_lat=0&to_lng=0", "", http.StatusBadRequest},
+		{http.MethodGet, "/v1/movers/999/estimate?from_lat=0&from_lng=0&to_lat=1&to_lng=1", "", http.StatusNotFound},
+
+		// Changing movers
+		{http.MethodPut, "/v1/movers/abc", `{}`, http.StatusBadRequest},
+		{http.MethodPut, "/v1/movers/999", `{"name": "Ghost", "telephone_number": "+15550400001"}`, http.StatusNotFound},
+		{http.MethodPut, "/v1/movers/1", `{"name": "Rapid Movers", "telephone_number": "+15615557689"}`, http.StatusConflict},
+		{http.MethodPatch, "/v1/movers/1", `{"telephone_number": "+15617384568"}`, http.StatusConflict},
+		{http.MethodPatch, "/v1/movers/1", `{"rating": -1}`, http.StatusBadRequest},
+		{http.MethodDelete, "/v1/movers/abc", "", http.StatusBadRequest},
+		{http.MethodDelete, "/v1/movers/999", "", http.StatusNotFound},
+		{http.MethodDelete, "/v1/movers/1?dry_run=maybe", "", http.StatusBadRequest},
+		{http.MethodDelete, "/v1/movers", `{"ids": []}`, http.StatusBadRequest},
+		{http.MethodPost, "/v1/movers/1/restore", "", http.StatusConflict},
+		{http.MethodPost, "/v1/movers/999/restore", "", http.StatusNotFound},
+
+		// Reviews
+		{http.MethodPost, "/v1/movers/abc/review", `{"rating": 4}`, http.StatusBadRequest},
+		{http.MethodPost, "/v1/movers/999/review", `{"rating": 4}`, http.StatusNotFound},
+		{http.MethodPost, "/v1/movers/1/review", `{"rating": 6}`, http.StatusBadRequest},
+		{http.MethodPost, "/v1/movers/1/review", `{"rating": 4, "weight": 10}`, http.StatusBadRequest},
+		{http.MethodPost, "/v1/movers/1/review", `{}`, http.StatusBadRequest},
+		{http.MethodPost, "/v1/movers/1/review", "", http.StatusBadRequest},
+		{http.MethodPost, "/v1/movers/1/response-time", `{"minutes": -5}`, http.StatusBadRequest},
+		{http.MethodPost, "/v1/movers/1/review/rank-impact", `{"rating": 9}`, http.StatusBadRequest},
+		{http.MethodGet, "/v1/movers/999/ratings/histogram", "", http.StatusNotFound},
+
+		// Admin endpoints without the key
+		{http.MethodPost, "/v1/movers/recompute", "", http.StatusUnauthorized},
+		{http.MethodPost, "/v1/movers/1/verify", "", http.StatusUnauthorized},
+		{http.MethodPost, "/v1/movers/1/feature", "", http.StatusUnauthorized},
+		{http.MethodDelete, "/v1/movers/1/reviews/1", "", http.StatusUnauthorized},
+		{http.MethodPost, "/v1/admin/readonly", `{"enabled": true}`, http.StatusUnauthorized},
+
+		// Routing
+		{http.MethodPatch, "/v1/movers", "", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/v1/nothing-here", "", http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.method+" "+test.path, func(t *testing.T) {
+			recorder := doRequest(newTestRouter(t), test.method, test.path, test.body)
+			expectStatus(t, recorder, test.want)
+		})
+	}
+}
+
+// The same error paths of the admin endpoints, with the admin key
+func TestAdminErrorStatusCodes(t *testing.T) {
+	tests := []struct {
+		method, path, body string
+		want               int
+	}{
+		{http.MethodPost, "/v1/movers/abc/verify", "", http.StatusBadRequest},
+		{http.MethodPost, "/v1/movers/999/verify", "", http.StatusNotFound},
+		{http.MethodPost, "/v1/movers/999/feature", "", http.StatusNotFound},
+		{http.MethodDelete, "/v1/movers/1/reviews/abc", "", http.StatusBadRequest},
+		{http.MethodDelete, "/v1/movers/1/reviews/999", "", http.StatusNotFound},
+		{http.MethodDelete, "/v1/movers/999/reviews/1", "", http.StatusNotFound},
+		{http.MethodPost, "/v1/admin/readonly", `{}`, http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		t.Run(test.method+" "+test.path, func(t *testing.T) {
+			recorder := adminRequest(newTestRouter(t), test.method, test.path, test.body)
+			expectStatus(t, recorder, test.want)
+		})
+	}
+}
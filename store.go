@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"time"
+)
+
+// errMoverNotFound is returned by stores for unknown IDs, and for soft-deleted movers where
+// only active ones are looked up
+var errMoverNotFound = errors.New("mover not found")
+
+// errReviewNotFound is returned when a review doesn't exist or belongs to another mover
+var errReviewNotFound = errors.New("review not found")
+
+// noExclusion is passed as excludeID when a new mover is checked, IDs start at 1
+const noExclusion = 0
+
+// MoverStore is the storage behind the handlers. Handlers hold its lock through readLocked and
+// writeLocked, so a request sees a consistent snapshot and the other methods assume the caller
+// already holds it. Movers are passed by value, changes are only kept through Update.
+// Writes return storage failures as errors, handlers answer them with 500
+type MoverStore interface {
+	RLock()
+	RUnlock()
+	Lock()
+	Unlock()
+
+	// All returns every mover including soft-deleted ones, List only the active ones
+	All() []mover
+	List() []mover
+	// Get returns an active mover, Find soft-deleted ones too
+	Get(id int) (mover, error)
+	Find(id int) (mover, error)
+	// ByTelNumber returns the active mover the number belongs to, in any of the usual formats
+	ByTelNumber(telNumber string) (mover, error)
+
+	// NameTaken and TelNumberTaken report whether another mover than excludeID uses the name or number.
+	// Updates exclude the mover being changed, so keeping its own name or number isn't a conflict
+	NameTaken(name string, excludeID int) bool
+	TelNumberTaken(telNumber string, excludeID int) bool
+
+	// Add stores new movers with consecutive IDs assigned by the store and returns them.
+	// Either all of them are stored or none
+	Add(movers ...mover) ([]mover, error)
+	// Update replaces the stored mover with the same ID
+	Update(m mover) error
+	// Delete soft-deletes an active mover, the record is kept so it can be restored
+	Delete(id int, at time.Time) error
+
+	// AddReview stores a review with an ID assigned by the store, IDs of deleted reviews aren't reused
+	AddReview(moverId int, reviewerId string, rating, weight float64) (review, error)
+	// DeleteReview removes a review of the mover and returns it
+	DeleteReview(moverId, reviewId int) (review, error)
+	// HasReviewFrom reports whether the reviewer already reviewed the mover
+	HasReviewFrom(moverId int, reviewerId string) bool
+	// Reviews returns the stored reviews of one mover, oldest first
+	Reviews(moverId int) []review
+
+	// Atomically runs fn as one unit of writes. When fn returns an error or the changes can't be
+	// committed, every change fn made is undone. Calls within fn join the outer unit
+	Atomically(fn func() error) error
+}
+
+// queryingStore is implemented by stores that filter and aggregate in the database,
+// so listing doesn't have to load every mover
+type queryingStore interface {
+	// ListMatching returns the active movers matching the filters of the options, in any order
+	ListMatching(options listOptions) []mover
+	// MeanRating is the mean rating of the active movers
+	MeanRating() float64
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"path/filepath"
+	"testing"
+)
+
+// storeBackends open a fresh store seeded with the seed movers, one per STORE value
+var storeBackends = map[string]func(t *testing.T) MoverStore{
+	memoryBackend: func(t *testing.T) MoverStore {
+		return newMemoryStore(defaultMovers())
+	},
+	sqliteBackend: func(t *testing.T) MoverStore {
+		store, err := newSQLiteStore(filepath.Join(t.TempDir(), "movers.db"), defaultMovers())
+		if err != nil {
+			t.Fatalf("opening SQLite store: %v", err)
+		}
+		t.Cleanup(func() { _ = store.db.Close() })
+		return store
+	},
+}
+
+const newMoverBody = `{"name": "Parity Movers", "telephone_number": "+15550001111", "rating": 4.1, "jobs_done": 10, "hourly_rate": 80}`
+
+// The same sequence of requests gets the same answers and leaves the same movers behind on every store
+func TestHandlersBehaveTheSameOnEveryStore(t *testing.T) {
+	steps := []struct {
+		method, path, body string
+		admin              bool
+		want               int
+	}{
+		{http.MethodPost, "/v1/movers", newMoverBody, false, http.StatusCreated},
+		{http.MethodPost, "/v1/movers", newMoverBody, false, http.StatusConflict},
+		{http.MethodPost, "/v1/movers/1/review", `{"rating": 5, "reviewer_id": "alice"}`, false, http.StatusOK},
+		{http.MethodPost, "/v1/movers/1/review", `{"rating": 1, "reviewer_id": "alice"}`, false, http.StatusConflict},
+		{http.MethodPost, "/v1/movers/2/review", `{"rating": 3, "weight": 2}`, false, http.StatusOK},
+		{http.MethodPatch, "/v1/movers/2", `{"hourly_rate": 99}`, false, http.StatusOK},
+		{http.MethodDelete, "/v1/movers/3", "", false, http.StatusOK},
+		{http.MethodPost, "/v1/movers/3/restore", "", false, http.StatusOK},
+		{http.MethodDelete, "/v1/movers", `{"ids": [4, 99]}`, false, http.StatusOK},
+		// The second entry reuses a seed mover's number, so the whole batch is rejected
+		{http.MethodPost, "/v1/movers/bulk", `[{"name": "Bulk One", "telephone_number": "+15550002222"}, {"name": "Bulk Two", "telephone_number": "+15615557689"}]`, false, http.StatusBadRequest},
+		{http.MethodPost, "/v1/movers/bulk", `[{"name": "Bulk One", "telephone_number": "+15550002222"}]`, false, http.StatusCreated},
+		{http.MethodDelete, "/v1/movers/1/reviews/1", "", true, http.StatusOK},
+		{http.MethodDelete, "/v1/movers/1/reviews/1", "", true, http.StatusNotFound},
+		{http.MethodPost, "/v1/movers/recompute", "", true, http.StatusOK},
+		{http.MethodGet, "/v1/movers/99", "", false, http.StatusNotFound},
+		{http.MethodGet, "/v1/movers/4", "", false, http.StatusNotFound},
+	}
+
+	final := map[string]string{}
+	for name, openStore := range storeBackends {
+		t.Run(name, func(t *testing.T) {
+			router := initializeRouter(testConfig(), openStore(t))
+			for _, step := range steps {
+				headers := []string{}
+				if step.admin {
+					headers = append(headers, "X-API-Key", testAPIKey)
+				}
+				recorder := doRequest(router, step.method, step.path, step.body, headers...)
+				if recorder.Code != step.want {
+					t.Fatalf("%s %s: status %d, want %d, body %s", step.method, step.path, recorder.Code, step.want, recorder.Body.String())
+				}
+			}
+			recorder := doRequest(router, http.MethodGet, "/v1/movers?sort=id&fields=id,name,rating,review_count,jobs_done,hourly_rate,telephone_number", "")
+			expectStatus(t, recorder, http.StatusOK)
+			final[name] = recorder.Body.String()
+		})
+	}
+	if final[memoryBackend] != final[sqliteBackend] {
+		t.Errorf("stores ended up with different movers:\nmemory %s\nsqlite %s", final[memoryBackend], final[sqliteBackend])
+	}
+}
+
+// handlerSuites are the tests that get their store from newTestStore. Tests that open every
+// store themselves or test one store's internals aren't listed
+var handlerSuites = []struct {
+	name string
+	run  func(t *testing.T)
+}{
+	{"OversizedBodyIsRejected", TestOversizedBodyIsRejected},
+	{"NameVariantsConflict", TestNameVariantsConflict},
+	{"TelNumberFormatsCollide", TestTelNumberFormatsCollide},
+	{"UpdateTelNumberUniqueness", TestUpdateTelNumberUniqueness},
+	{"RecommendationFeedWalk", TestRecommendationFeedWalk},
+	{"EstimateRejectsNonFiniteCoordinates", TestEstimateRejectsNonFiniteCoordinates},
+	{"ETagDiffersPerEncoding", TestETagDiffersPerEncoding},
+	{"EveryMoverChangePublishesAnEvent", TestEveryMoverChangePublishesAnEvent},
+	{"FuzzyNameSearch", TestFuzzyNameSearch},
+	{"RateAndRatingFilters", TestRateAndRatingFilters},
+	{"InvalidFiltersAreAllReported", TestInvalidFiltersAreAllReported},
+	{"SlowBodyDoesNotBlockReaders", TestSlowBodyDoesNotBlockReaders},
+	{"ImplausibleReportNeedsAdminKey", TestImplausibleReportNeedsAdminKey},
+	{"RankedCacheReflectsWritesImmediately", TestRankedCacheReflectsWritesImmediately},
+	{"FeaturedMoverOutranksHigherRated", TestFeaturedMoverOutranksHigherRated},
+	{"ClientsCannotFeatureMovers", TestClientsCannotFeatureMovers},
+	{"ClientsCannotSetReviewCount", TestClientsCannotSetReviewCount},
+	{"EstablishedMoverOutranksSingleReview", TestEstablishedMoverOutranksSingleReview},
+	{"HighReviewMovesMoverUp", TestHighReviewMovesMoverUp},
+	{"MostReviewedRelativeOrdersByRatio", TestMostReviewedRelativeOrdersByRatio},
+	{"DuplicateIdsSortStably", TestDuplicateIdsSortStably},
+	{"NearbyRejectsNonFiniteValues", TestNearbyRejectsNonFiniteValues},
+	{"AuthenticatedClientsAreNotThrottled", TestAuthenticatedClientsAreNotThrottled},
+	{"ReadOnlyMode", TestReadOnlyMode},
+	{"DoubleSubmittedReviewIsRecordedOnce", TestDoubleSubmittedReviewIsRecordedOnce},
+	{"DifferentReviewFromSameSubmitterIsNotReplayed", TestDifferentReviewFromSameSubmitterIsNotReplayed},
+	{"RecomputeCorrectsDriftAboveTheBaseline", TestRecomputeCorrectsDriftAboveTheBaseline},
+	{"DuplicateReviewerIsRejected", TestDuplicateReviewerIsRejected},
+	{"RejectDuplicateReviewersFromEnv", TestRejectDuplicateReviewersFromEnv},
+	{"DeletingMiddleReviewRecomputesAverage", TestDeletingMiddleReviewRecomputesAverage},
+	{"ErrorStatusCodes", TestErrorStatusCodes},
+	{"AdminErrorStatusCodes", TestAdminErrorStatusCodes},
+	{"JobsDoneMustNotBeNegative", TestJobsDoneMustNotBeNegative},
+	{"JSONAndFormBodiesGiveTheSameResult", TestJSONAndFormBodiesGiveTheSameResult},
+	{"ControlCharactersAreRejected", TestControlCharactersAreRejected},
+	{"FormNumbersMustBeFinite", TestFormNumbersMustBeFinite},
+	{"EmptyBulkImportIsRejected", TestEmptyBulkImportIsRejected},
+}
+
+// The handler suites run on the memory store as plain tests, this runs them on every other store too
+func TestHandlerSuitesOnEveryStore(t *testing.T) {
+	for name := range storeBackends {
+		if name == memoryBackend {
+			continue
+		}
+		t.Run(name, func(t *testing.T) {
+			defer func(previous string) { testBackend = previous }(testBackend)
+			testBackend = name
+			for _, suite := range handlerSuites {
+				t.Run(suite.name, suite.run)
+			}
+		})
+	}
+}
+
+func TestAtomicallyUndoesFailedWrites(t *testing.T) {
+	for name, openStore := range storeBackends {
+		t.Run(name, func(t *testing.T) {
+			store := openStore(t)
+			before := store.All()
+
+			failure := errors.New("third step failed")
+			err := store.Atomically(func() error {
+				if _, err := store.Add(mover{Name: "Rolled Back", TelephoneNumber: "+15550003333"}); err != nil {
+					return err
+				}
+				if _, err := store.AddReview(1, "bob", 5, 1); err != nil {
+					return err
+				}
+				return failure
+			})
+			if !errors.Is(err, failure) {
+				t.Fatalf("Atomically returned %v, want %v", err, failure)
+			}
+
+			if after := store.All(); len(after) != len(before) {
+				t.Errorf("%d movers after the rollback, want %d", len(after), len(before))
+			}
+			if store.NameTaken("Rolled Back", noExclusion) || store.TelNumberTaken("+15550003333", noExclusion) {
+				t.Error("the rolled back mover is still indexed")
+			}
+			if reviews := store.Reviews(1); len(reviews) != 0 {
+				t.Errorf("rolled back review is still stored: %v", reviews)
+			}
+
+			// The store keeps working after a rollback
+			added, err := store.Add(mover{Name: "Committed", TelephoneNumber: "+15550004444"})
+			if err != nil || added[0].ID != len(before)+1 {
+				t.Fatalf("Add after rollback = %v, %v", added, err)
+			}
+		})
+	}
+}
+
+// failingStore is a memory store whose every Update fails, as if the disk were full
+type failingStore struct {
+	*memoryStore
+}
+
+func (store failingStore) Update(m mover) error {
+	return errors.New("disk full")
+}
+
+func TestFailedWriteRollsBackAndAnswers500(t *testing.T) {
+	store := failingStore{newMemoryStore(defaultMovers())}
+	router := initializeRouter(testConfig(), store)
+	before := decode[mover](t, doRequest(router, http.MethodGet, "/v1/movers/1?precision=full", ""))
+
+	// The review is stored before the mover's totals, the failed Update has to take it back
+	recorder := doRequest(router, http.MethodPost, "/v1/movers/1/review", `{"rating": 1, "reviewer_id": "carol"}`)
+	expectStatus(t, recorder, http.StatusInternalServerError)
+
+	if reviews := store.Reviews(1); len(reviews) != 0 {
+		t.Errorf("review kept after the failed write: %v", reviews)
+	}
+	after := decode[mover](t, doRequest(router, http.MethodGet, "/v1/movers/1?precision=full", ""))
+	if after.Rating != before.Rating || after.ReviewCount != before.ReviewCount || after.JobsAmount != before.JobsAmount {
+		t.Errorf("mover changed by the failed write: before %+v, after %+v", before, after)
+	}
+
+	// Nothing was recorded, so the same reviewer isn't a duplicate on the next try
+	recorder = doRequest(router, http.MethodPost, "/v1/movers/1/review", `{"rating": 1, "reviewer_id": "carol"}`)
+	expectStatus(t, recorder, http.StatusInternalServerError)
+}
+
+// Every MoverStore keeps the contract documented on the interface
+func TestMoverStoreContract(t *testing.T) {
+	for name, openStore := range storeBackends {
+		t.Run(name, func(t *testing.T) {
+			store := openStore(t)
+			seeded := len(defaultMovers())
+
+			added, err := store.Add(mover{Name: "First Added", TelephoneNumber: "+15551200001"}, mover{Name: "Second Added", TelephoneNumber: "+15551200002"})
+			if err != nil || len(added) != 2 || added[0].ID != seeded+1 || added[1].ID != seeded+2 {
+				t.Fatalf("Add = %+v, %v, want IDs %d and %d", added, err, seeded+1, seeded+2)
+			}
+			if got, err := store.Get(added[1].ID); err != nil || got.Name != "Second Added" {
+				t.Errorf("Get(%d) = %+v, %v", added[1].ID, got, err)
+			}
+			if got, err := store.ByTelNumber("+1 555 120 0001"); err != nil || got.ID != added[0].ID {
+				t.Errorf("ByTelNumber = %+v, %v, want mover %d", got, err, added[0].ID)
+			}
+			if !store.NameTaken(" first ADDED", noExclusion) || store.NameTaken("First Added", added[0].ID) {
+				t.Error("NameTaken doesn't match the name or ignore the excluded mover")
+			}
+
+			// A copy handed out by List can't change the stored mover, Update can
+			listed := store.List()
+			listed[0].Name = "Changed Copy"
+			if got, _ := store.Get(listed[0].ID); got.Name == "Changed Copy" {
+				t.Error("changing a listed mover changed the store")
+			}
+			updated := added[0]
+			updated.TelephoneNumber = "+15551200003"
+			if err := store.Update(updated); err != nil {
+				t.Fatalf("Update: %v", err)
+			}
+			if store.TelNumberTaken("+15551200001", noExclusion) || !store.TelNumberTaken("+15551200003", noExclusion) {
+				t.Error("Update didn't move the number")
+			}
+			if err := store.Update(mover{ID: 999, Name: "Missing"}); !errors.Is(err, errMoverNotFound) {
+				t.Errorf("Update of a missing mover = %v, want errMoverNotFound", err)
+			}
+
+			// Soft-deleted movers are only found by Find and All
+			if err := store.Delete(added[0].ID, seededAt); err != nil {
+				t.Fatalf("Delete: %v", err)
+			}
+			if err := store.Delete(added[0].ID, seededAt); !errors.Is(err, errMoverNotFound) {
+				t.Errorf("second Delete = %v, want errMoverNotFound", err)
+			}
+			if _, err := store.Get(added[0].ID); !errors.Is(err, errMoverNotFound) {
+				t.Errorf("Get of a deleted mover = %v, want errMoverNotFound", err)
+			}
+			if found, err := store.Find(added[0].ID); err != nil || !found.Deleted {
+				t.Errorf("Find of a deleted mover = %+v, %v", found, err)
+			}
+			if _, err := store.ByTelNumber("+15551200003"); !errors.Is(err, errMoverNotFound) {
+				t.Errorf("ByTelNumber of a deleted mover = %v, want errMoverNotFound", err)
+			}
+			if !store.TelNumberTaken("+15551200003", noExclusion) {
+				t.Error("a deleted mover's number was released")
+			}
+			if len(store.List()) != seeded+1 || len(store.All()) != seeded+2 {
+				t.Errorf("List %d and All %d movers, want %d and %d", len(store.List()), len(store.All()), seeded+1, seeded+2)
+			}
+
+			// Review IDs increase and aren't reused after a delete
+			first, _ := store.AddReview(1, "Dana", 4, 1)
+			second, _ := store.AddReview(1, "", 2, 2)
+			if _, err := store.DeleteReview(1, second.ID); err != nil {
+				t.Fatalf("DeleteReview: %v", err)
+			}
+			third, _ := store.AddReview(1, "", 5, 1)
+			if second.ID <= first.ID || third.ID <= second.ID {
+				t.Errorf("review IDs %d, %d, %d don't increase", first.ID, second.ID, third.ID)
+			}
+			if _, err := store.DeleteReview(2, first.ID); !errors.Is(err, errReviewNotFound) {
+				t.Errorf("DeleteReview of another mover's review = %v, want errReviewNotFound", err)
+			}
+			if reviews := store.Reviews(1); len(reviews) != 2 || reviews[0].ID != first.ID || reviews[1].ID != third.ID {
+				t.Errorf("Reviews = %+v, want reviews %d and %d", reviews, first.ID, third.ID)
+			}
+			if !store.HasReviewFrom(1, "dana") || store.HasReviewFrom(1, "") || store.HasReviewFrom(2, "Dana") {
+				t.Error("HasReviewFrom doesn't match reviewers per mover, case-insensitively")
+			}
+		})
+	}
+}
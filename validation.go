@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"math"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// fieldError explains why one field of a request body is invalid
+type fieldError struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+// fieldErrors lists every invalid field of a request body
+type fieldErrors []fieldError
+
+func (errs fieldErrors) Error() string {
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Field + " " + err.Reason
+	}
+	return strings.Join(messages, "; ")
+}
+
+// registerValidators teaches Gin's validator the custom tags of the mover struct and makes
+// it report fields by their JSON names
+func registerValidators() {
+	validate, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	// Accepts the usual formatting characters, the number is stored normalized
+	_ = validate.RegisterValidation("telephone", func(field validator.FieldLevel) bool {
+		return telNumberPattern.MatchString(normalizeTelNumber(field.Field().String()))
+	})
+	_ = validate.RegisterValidation("notblank", func(field validator.FieldLevel) bool {
+		return strings.TrimSpace(field.Field().String()) != ""
+	})
+	_ = validate.RegisterValidation("nocontrol", func(field validator.FieldLevel) bool {
+		return !containsControl(field.Field().String())
+	})
+	_ = validate.RegisterValidation("finite", func(field validator.FieldLevel) bool {
+		return isFinite(field.Field().Float())
+	})
+}
+
+// containsControl reports whether the text holds a control character, 0x00 to 0x1F or 0x7F.
+// Newlines, null bytes and ANSI escapes in echoed text would corrupt CSV exports and log lines
+func containsControl(text string) bool {
+	return strings.ContainsFunc(text, func(r rune) bool {
+		return r < 0x20 || r == 0x7F
+	})
+}
+
+// isFinite reports whether x is neither NaN nor infinite. Range checks are false for NaN, so it
+// would pass them, and neither can be encoded as JSON. Forms and query params parse both
+func isFinite(x float64) bool {
+	return !math.IsNaN(x) && !math.IsInf(x, 0)
+}
+
+// validateFields runs the binding tags of a single value, e.g. one entry of a bulk import
+func validateFields(value any) error {
+	if err := binding.Validator.ValidateStruct(value); err != nil {
+		if fields, ok := toFieldErrors(err); ok {
+			return fields
+		}
+		return err
+	}
+	return nil
+}
+
+// toFieldErrors translates validator and JSON type errors into field-level errors
+func toFieldErrors(err error) (fieldErrors, bool) {
+	var fields fieldErrors
+	if errors.As(err, &fields) {
+		return fields, true
+	}
+
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		fields := make(fieldErrors, 0, len(validationErrs))
+		for _, validationErr := range validationErrs {
+			fields = append(fields, fieldError{Field: validationErr.Field(), Reason: validationReason(validationErr)})
+		}
+		return fields, true
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return fieldErrors{{Field: typeErr.Field, Reason: "should be " + jsonTypeName(typeErr.Type)}}, true
+	}
+	return nil, false
+}
+
+func validationReason(err validator.FieldError) string {
+	switch err.Tag() {
+	case "required", "notblank":
+		return "is required"
+	case "gt":
+		return "should be greater than " + err.Param()
+	case "gte":
+		return "should be at least " + err.Param()
+	case "lte":
+		return "should be at most " + err.Param()
+	case "max":
+		return fmt.Sprintf("should be at most %s characters long", err.Param())
+	case "telephone":
+		return "should be an E.164 telephone number, e.g. +15615557689"
+	case "nocontrol":
+		return "should not contain control characters"
+	case "finite":
+		return "should be a finite number"
+	}
+	return "is not valid"
+}
+
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "an integer"
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	}
+	return "an object"
+}
+
+// isFormBody reports whether the request body is an HTML form rather than JSON
+func isFormBody(context *gin.Context) bool {
+	contentType := context.ContentType()
+	return contentType == binding.MIMEPOSTForm || contentType == binding.MIMEMultipartPOSTForm
+}
+
+// bindBody binds a form body when the Content-Type says so, e.g. from legacy HTML forms, and JSON
+// otherwise. Any other Content-Type is still read as JSON like before forms were accepted.
+// Both go through the same binding tags, so validation is identical
+func bindBody(context *gin.Context, obj any) error {
+	if isFormBody(context) {
+		return context.ShouldBind(obj)
+	}
+	return context.ShouldBindJSON(obj)
+}
+
+// respondInvalidBody answers 400 with the invalid fields when binding failed on validation or a
+// mistyped field, otherwise falls back to respondBindError
+func respondInvalidBody(context *gin.Context, err error) {
+	if fields, ok := toFieldErrors(err); ok {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid fields", "fields": fields})
+		return
+	}
+	if isFormBody(context) {
+		respondBindError(context, err, "Invalid form data")
+		return
+	}
+	respondBindError(context, err, "Invalid JSON")
+}
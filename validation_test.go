@@ -0,0 +1,204 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestJobsDoneMustNotBeNegative(t *testing.T) {
+	router := newTestRouter(t)
+	full := func(jobs int) string {
+		return fmt.Sprintf(`{"name": "Jobs Movers", "telephone_number": "+15550900001", "jobs_done": %d}`, jobs)
+	}
+	for _, jobs := range []int{-1, -500} {
+		t.Run(fmt.Sprint(jobs), func(t *testing.T) {
+			for _, request := range []struct{ method, path, body string }{
+				{http.MethodPost, "/v1/movers", full(jobs)},
+				{http.MethodPost, "/v1/movers/bulk", "[" + full(jobs) + "]"},
+				{http.MethodPut, "/v1/movers/1", full(jobs)},
+				{http.MethodPatch, "/v1/movers/1", fmt.Sprintf(`{"jobs_done": %d}`, jobs)},
+			} {
+				recorder := doRequest(router, request.method, request.path, request.body)
+				if recorder.Code != http.StatusBadRequest {
+					t.Errorf("%s %s: status %d, want 400, body %s", request.method, request.path, recorder.Code, recorder.Body.String())
+				}
+			}
+		})
+	}
+	if m := decode[mover](t, doRequest(router, http.MethodGet, "/v1/movers/1", "")); m.JobsAmount != defaultMovers()[0].JobsAmount {
+		t.Errorf("jobs_done changed to %d by rejected requests", m.JobsAmount)
+	}
+
+	// A large count is accepted and stored as sent
+	recorder := doRequest(router, http.MethodPost, "/v1/movers", full(987654321))
+	expectStatus(t, recorder, http.StatusCreated)
+	if jobs := decode[mover](t, recorder).JobsAmount; jobs != 987654321 {
+		t.Errorf("stored jobs_done %d, want 987654321", jobs)
+	}
+	recorder = doRequest(router, http.MethodPatch, "/v1/movers/1", `{"jobs_done": 2000000}`)
+	expectStatus(t, recorder, http.StatusOK)
+	if jobs := decode[mover](t, recorder).JobsAmount; jobs != 2000000 {
+		t.Errorf("patched jobs_done %d, want 2000000", jobs)
+	}
+}
+
+// postForm sends a form-encoded body like a legacy HTML form
+func postForm(router http.Handler, path string, form url.Values) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestJSONAndFormBodiesGiveTheSameResult(t *testing.T) {
+	cases := []struct {
+		name string
+		path string
+		json string
+		form url.Values
+	}{
+		{"valid mover", "/v1/movers",
+			`{"name": "Form Movers", "telephone_number": "+15551100001", "rating": 4.3, "jobs_done": 12, "review_count": 3, "hourly_rate": 99.5, "services": ["local", "packing"]}`,
+			url.Values{"name": {"Form Movers"}, "telephone_number": {"+15551100001"}, "rating": {"4.3"}, "jobs_done": {"12"}, "review_count": {"3"}, "hourly_rate": {"99.5"}, "services": {"local", "packing"}}},
+		{"invalid fields", "/v1/movers",
+			`{"name": "Form Movers", "telephone_number": "not a number", "rating": 7, "jobs_done": -1}`,
+			url.Values{"name": {"Form Movers"}, "telephone_number": {"not a number"}, "rating": {"7"}, "jobs_done": {"-1"}}},
+		{"missing name", "/v1/movers",
+			`{"telephone_number": "+15551100001"}`,
+			url.Values{"telephone_number": {"+15551100001"}}},
+		{"taken name", "/v1/movers",
+			`{"name": "rapid movers", "telephone_number": "+15551100001"}`,
+			url.Values{"name": {"rapid movers"}, "telephone_number": {"+15551100001"}}},
+		{"taken number", "/v1/movers",
+			`{"name": "Form Movers", "telephone_number": "+1 561-555-7689"}`,
+			url.Values{"name": {"Form Movers"}, "telephone_number": {"+1 561-555-7689"}}},
+		{"review", "/v1/movers/1/review",
+			`{"rating": 4.5, "weight": 2, "reviewer_id": "form-user"}`,
+			url.Values{"rating": {"4.5"}, "weight": {"2"}, "reviewer_id": {"form-user"}}},
+		{"invalid review", "/v1/movers/1/review",
+			`{"rating": 6}`,
+			url.Values{"rating": {"6"}}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			asJSON := doRequest(newTestRouter(t), http.MethodPost, tc.path, tc.json)
+			asForm := postForm(newTestRouter(t), tc.path, tc.form)
+			// The two requests may be stored a second apart
+			jsonBody, formBody := decode[map[string]any](t, asJSON), decode[map[string]any](t, asForm)
+			for _, timestamp := range []string{"created_at", "updated_at"} {
+				delete(jsonBody, timestamp)
+				delete(formBody, timestamp)
+			}
+			if asJSON.Code != asForm.Code || !reflect.DeepEqual(jsonBody, formBody) {
+				t.Errorf("JSON answered %d %s\nform answered %d %s", asJSON.Code, asJSON.Body.String(), asForm.Code, asForm.Body.String())
+			}
+		})
+	}
+}
+
+func TestControlCharactersAreRejected(t *testing.T) {
+	router := newTestRouter(t)
+	for _, name := range []string{"Evil\nMovers", "Evil\x00Movers", "Evil\x1b[31mMovers", "Evil\x7fMovers", "Evil\r\nMovers"} {
+		t.Run(fmt.Sprintf("%q", name), func(t *testing.T) {
+			body := fmt.Sprintf(`{"name": %q, "telephone_number": "+15551500001"}`, name)
+			for _, request := range []struct{ method, path, body string }{
+				{http.MethodPost, "/v1/movers", body},
+				{http.MethodPost, "/v1/movers/bulk", "[" + body + "]"},
+				{http.MethodPut, "/v1/movers/1", body},
+				{http.MethodPatch, "/v1/movers/1", fmt.Sprintf(`{"name": %q}`, name)},
+				{http.MethodPost, "/v1/movers/1/review", fmt.Sprintf(`{"rating": 4, "reviewer_id": %q}`, name)},
+			} {
+				recorder := doRequest(router, request.method, request.path, request.body)
+				if recorder.Code != http.StatusBadRequest {
+					t.Errorf("%s %s: status %d, want 400, body %s", request.method, request.path, recorder.Code, recorder.Body.String())
+				}
+			}
+			form := postForm(router, "/v1/movers", url.Values{"name": {name}, "telephone_number": {"+15551500001"}})
+			expectStatus(t, form, http.StatusBadRequest)
+		})
+	}
+
+	// Nothing reached the store, so the CSV export has one line per mover
+	recorder := doRequest(router, http.MethodGet, "/v1/movers.csv", "")
+	expectStatus(t, recorder, http.StatusOK)
+	if lines := strings.Count(recorder.Body.String(), "\n"); lines != len(defaultMovers())+1 {
+		t.Errorf("CSV export has %d lines, want a header and %d movers", lines, len(defaultMovers()))
+	}
+	if strings.ContainsAny(recorder.Body.String(), "\x00\x1b\x7f") {
+		t.Error("control characters in the CSV export")
+	}
+}
+
+func TestFormNumbersMustBeFinite(t *testing.T) {
+	weightRange := fmt.Sprintf("Weight should be in range between %g and %g", minReviewWeight, maxReviewWeight)
+	store := newTestStore(t)
+	router := initializeRouter(testConfig(), store)
+	reviewCases := []struct {
+		form url.Values
+		want string
+	}{
+		{url.Values{"rating": {"NaN"}}, "Provided rate should be in range between 0 and 5"},
+		{url.Values{"rating": {"Inf"}}, "Provided rate should be in range between 0 and 5"},
+		{url.Values{"rating": {"4"}, "weight": {"NaN"}}, weightRange},
+		{url.Values{"rating": {"4"}, "weight": {"Inf"}}, weightRange},
+		// An emptied field is missing, not a zero-star review
+		{url.Values{"rating": {""}}, "Rating is required"},
+		{url.Values{"rating": {""}, "weight": {"2"}, "reviewer_id": {"someone"}}, "Rating is required"},
+	}
+	for _, tc := range reviewCases {
+		recorder := postForm(router, "/v1/movers/1/review", tc.form)
+		expectStatus(t, recorder, http.StatusBadRequest)
+		if message := decode[map[string]string](t, recorder)["error"]; message != tc.want {
+			t.Errorf("%s: error %q, want %q", tc.form.Encode(), message, tc.want)
+		}
+	}
+	if reviews := store.Reviews(1); len(reviews) != 0 {
+		t.Errorf("rejected reviews were stored: %+v", reviews)
+	}
+
+	moverCases := map[string]url.Values{
+		"rating":      {"rating": {"NaN"}},
+		"hourly_rate": {"hourly_rate": {"Inf"}},
+		"latitude":    {"latitude": {"NaN"}},
+		"longitude":   {"longitude": {"-Inf"}},
+	}
+	for field, form := range moverCases {
+		form.Set("name", "Infinite Movers")
+		form.Set("telephone_number", "+15551700001")
+		recorder := postForm(router, "/v1/movers", form)
+		expectStatus(t, recorder, http.StatusBadRequest)
+		fields := decode[struct{ Fields fieldErrors }](t, recorder).Fields
+		if len(fields) != 1 || fields[0].Field != field || fields[0].Reason != "should be a finite number" {
+			t.Errorf("%s: fields %+v", form.Encode(), fields)
+		}
+	}
+
+	// An empty weight falls back to the default, and the list still encodes
+	expectStatus(t, postForm(router, "/v1/movers/1/review", url.Values{"rating": {"4"}, "weight": {""}}), http.StatusOK)
+	if reviews := store.Reviews(1); len(reviews) != 1 || reviews[0].Weight != defaultReviewWeight {
+		t.Errorf("reviews %+v, want one with the default weight", reviews)
+	}
+	expectStatus(t, doRequest(router, http.MethodGet, "/v1/movers", ""), http.StatusOK)
+}
+
+func TestEmptyBulkImportIsRejected(t *testing.T) {
+	router := newTestRouter(t)
+	for _, path := range []string{"/v1/movers/bulk", "/v1/movers/bulk?mode=partial"} {
+		for _, body := range []string{"null", "[]", " null\n"} {
+			recorder := doRequest(router, http.MethodPost, path, body)
+			expectStatus(t, recorder, http.StatusBadRequest)
+			if got := decode[map[string]any](t, recorder)["error"]; got != "at least one mover is required" {
+				t.Errorf("POST %s %q: error %q", path, body, got)
+			}
+		}
+	}
+	if movers := decode[[]mover](t, doRequest(router, http.MethodGet, "/v1/movers", "")); len(movers) != len(defaultMovers()) {
+		t.Errorf("%d movers after empty imports, want %d", len(movers), len(defaultMovers()))
+	}
+}
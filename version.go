@@ -0,0 +1,26 @@
+package main
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"runtime"
+)
+
+// Build info, injected at build time:
+//
+//	go build -ldflags "-X main.version=1.4.0 -X main.commit=$(git rev-parse HEAD) -X main.buildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+var (
+	version   = "dev"
+	commit    = "unknown"
+	buildTime = "unknown"
+)
+
+// GET request. Which build is running, for provenance in bug reports. Unlike /healthz it says nothing about liveness
+func getVersion(context *gin.Context) {
+	context.JSON(http.StatusOK, gin.H{
+		"version":    version,
+		"commit":     commit,
+		"build_time": buildTime,
+		"go_version": runtime.Version(),
+	})
+}
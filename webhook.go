@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+const (
+	webhookTimeout  = 5 * time.Second // per attempt
+	webhookAttempts = 3
+	webhookBackoff  = 500 * time.Millisecond // doubled after every failed attempt
+)
+
+// reviewEvent is the payload POSTed to WEBHOOK_URL after a review is recorded
+type reviewEvent struct {
+	MoverID       int       `json:"mover_id"`
+	Rating        float64   `json:"rating"`
+	AverageRating float64   `json:"average_rating"`
+	ReviewedAt    time.Time `json:"reviewed_at"`
+}
+
+// webhookNotifier delivers events to an outbound webhook. A nil notifier, used when
+// WEBHOOK_URL is unset, skips every event
+type webhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func newWebhookNotifier(url string) *webhookNotifier {
+	if url == "" {
+		return nil
+	}
+	return &webhookNotifier{url: url, client: &http.Client{Timeout: webhookTimeout}}
+}
+
+// notifyReview sends the event in the background so the client response isn't blocked,
+// retrying with backoff and only logging when every attempt failed
+func (notifier *webhookNotifier) notifyReview(event reviewEvent) {
+	if notifier == nil {
+		return
+	}
+	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				slog.Error("Review webhook panicked", "mover_id", event.MoverID, "panic", recovered)
+			}
+		}()
+
+		body, err := json.Marshal(event)
+		if err != nil {
+			slog.Error("Review webhook not sent", "mover_id", event.MoverID, "error", err)
+			return
+		}
+
+		backoff := webhookBackoff
+		for attempt := 1; ; attempt++ {
+			err = notifier.post(body)
+			if err == nil {
+				return
+			}
+			if attempt == webhookAttempts {
+				break
+			}
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+		slog.Warn("Review webhook failed", "mover_id", event.MoverID, "attempts", webhookAttempts, "error", err)
+	}()
+}
+
+func (notifier *webhookNotifier) post(body []byte) error {
+	response, err := notifier.client.Post(notifier.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with %s", response.Status)
+	}
+	return nil
+}